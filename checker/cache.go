@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+)
+
+// scheduleKey идентифицирует одну страницу графика: вид спорта, корт и дата
+type scheduleKey struct {
+	Sport   string
+	CourtID string
+	Date    string
+}
+
+// scheduleEntry - результат загрузки страницы графика (ошибки тоже кешируем, чтобы не повторять запрос в цикле)
+// done закрывается, когда загрузка завершена - параллельные запросы того же ключа ждут его
+type scheduleEntry struct {
+	done  chan struct{}
+	slots []types.Slot
+	err   error
+}
+
+// scheduleCache хранит графики в рамках одного цикла проверки:
+// одинаковые (корт, дата) из разных подписок загружаются один раз. Безопасен для использования из нескольких горутин
+type scheduleCache struct {
+	source  SlotSource
+	mu      sync.Mutex
+	entries map[scheduleKey]*scheduleEntry
+	only    map[scheduleKey]bool // если задано - загружаются только эти страницы, остальные возвращают errNotDue
+
+	observedAt time.Time // когда результаты цикла записаны в жизненный цикл слотов (нулевое - не записаны)
+}
+
+func newScheduleCache(source SlotSource) *scheduleCache {
+	return &scheduleCache{
+		source:  source,
+		entries: make(map[scheduleKey]*scheduleEntry),
+	}
+}
+
+// restrict ограничивает цикл страницами keys (приоритетный опрос загружает только просроченные страницы)
+func (sc *scheduleCache) restrict(keys []scheduleKey) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.only = make(map[scheduleKey]bool, len(keys))
+	for _, key := range keys {
+		sc.only[key] = true
+	}
+}
+
+// get возвращает все свободные слоты страницы графика, загружая ее при первом обращении
+func (sc *scheduleCache) get(ctx context.Context, key scheduleKey) ([]types.Slot, error) {
+	sc.mu.Lock()
+	if sc.only != nil && !sc.only[key] {
+		sc.mu.Unlock()
+		return nil, errNotDue
+	}
+	entry, ok := sc.entries[key]
+	if !ok {
+		entry = &scheduleEntry{done: make(chan struct{})}
+		sc.entries[key] = entry
+		sc.mu.Unlock()
+
+		entry.slots, entry.err = sc.source.FetchSchedule(ctx, key.Sport, key.CourtID, key.Date)
+		close(entry.done)
+		return entry.slots, entry.err
+	}
+	sc.mu.Unlock()
+
+	// Страница уже загружается другой горутиной - ждем результат
+	select {
+	case <-entry.done:
+		return entry.slots, entry.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// results возвращает завершенные загрузки цикла (для учета здоровья разбора)
+func (sc *scheduleCache) results() map[scheduleKey]scheduleEntry {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	out := make(map[scheduleKey]scheduleEntry, len(sc.entries))
+	for key, entry := range sc.entries {
+		select {
+		case <-entry.done:
+			out[key] = scheduleEntry{slots: entry.slots, err: entry.err}
+		default:
+		}
+	}
+	return out
+}
+
+// prefetch загружает объединение страниц графика всех подписок пулом из workers горутин
+// (ошибки логируются при раздаче по подпискам). Отмена ctx прекращает выдачу новых задач
+func (sc *scheduleCache) prefetch(ctx context.Context, keys []scheduleKey, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan scheduleKey)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				sc.get(ctx, key)
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		select {
+		case jobs <- key:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// scheduleKeys возвращает страницы графика, нужные подписке (корты × даты)
+func (c *Checker) scheduleKeys(sub *storage.Subscription) []scheduleKey {
+	sport := types.SportOrDefault(sub.Sport)
+
+	// Конкретные даты подписки или выбранные дни недели - в обоих случаях не дальше HorizonDays вперед
+	// (по местному времени города). Дни без окон времени пропускаем - в них подписке ничего не подойдет
+	loc := types.CityLocation(sub.City)
+	var dates []string
+	if len(sub.Dates) > 0 {
+		for _, date := range upcomingDates(sub.Dates, time.Now().In(loc), HorizonDays) {
+			if len(sub.WindowsFor(weekdayOf(date))) > 0 {
+				dates = append(dates, date)
+			}
+		}
+	} else {
+		days := make([]string, 0, len(sub.Days))
+		for _, day := range sub.Days {
+			if len(sub.WindowsFor(day)) > 0 {
+				days = append(days, day)
+			}
+		}
+		dates = c.generateDates(days, HorizonDays, loc)
+	}
+
+	keys := make([]scheduleKey, 0, len(sub.Courts)*len(dates))
+	for _, courtID := range sub.Courts {
+		for _, date := range dates {
+			keys = append(keys, scheduleKey{Sport: sport, CourtID: courtID, Date: date})
+		}
+	}
+	return keys
+}
+
+// unionKeys собирает уникальные страницы графика по всем подпискам
+func (c *Checker) unionKeys(subscriptions []*storage.Subscription) []scheduleKey {
+	seen := make(map[scheduleKey]bool)
+	keys := make([]scheduleKey, 0)
+	for _, sub := range subscriptions {
+		if !isComplete(sub) {
+			continue
+		}
+		for _, key := range c.scheduleKeys(sub) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// isComplete проверяет, что в подписке выбраны районы, корты, дни и время для каждого из них
+func isComplete(sub *storage.Subscription) bool {
+	return len(sub.Districts) > 0 && len(sub.Courts) > 0 && (len(sub.Days) > 0 || len(sub.Dates) > 0) && sub.HasTimeWindows()
+}
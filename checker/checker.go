@@ -1,44 +1,114 @@
 package checker
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"log"
-	"strings"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"court-bot/parser"
 	"court-bot/storage"
 	"court-bot/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// checkNowTimeout ограничивает разовую проверку подписки по запросу пользователя
+const checkNowTimeout = 5 * time.Minute
+
+// HorizonDays - на сколько дней вперед (включая сегодня) проверяются графики подписок по дням недели
+const HorizonDays = 14
+
+// SlotSource - источник данных о кортах и свободных слотах (kluby.org, фикстуры, другие сервисы бронирования)
+type SlotSource interface {
+	// ListDistricts возвращает список районов города, где есть клубы для вида спорта
+	ListDistricts(ctx context.Context, city, sport string) ([]string, error)
+	// ListCourts возвращает клубы вида спорта в выбранных районах города
+	ListCourts(ctx context.Context, city, sport string, districts []string) ([]types.Court, error)
+	// FetchSchedule возвращает все свободные слоты корта на дату (без фильтрации по времени)
+	FetchSchedule(ctx context.Context, sport, courtID, date string) ([]types.Slot, error)
+}
+
 type Checker struct {
-	Bot   *tgbotapi.BotAPI
-	Store *storage.Storage
+	Bot     *tgbotapi.BotAPI
+	Store   *storage.Storage
+	Source  SlotSource
+	Workers int // количество параллельных загрузок графиков
+	// Schedule - как часто проверять страницы графика в зависимости от удаленности даты и времени суток
+	Schedule *PollSchedule
+
+	cycleMu     sync.Mutex  // циклы опроса не пересекаются: иначе оба сравнят слоты с одним прошлым состоянием
+	fullCycle   atomic.Bool // полный цикл по запросу администратора уже идет
+	status      cycleStatus
+	health      *healthTracker
+	unreachable *unreachableNotices
+	onAlert     func(text string)
 }
 
-func New(bot *tgbotapi.BotAPI, store *storage.Storage) *Checker {
+func New(bot *tgbotapi.BotAPI, store *storage.Storage, source SlotSource, workers int) *Checker {
+	if workers < 1 {
+		workers = 1
+	}
+	schedule, _ := ParsePollSchedule(DefaultPollSchedule)
 	return &Checker{
-		Bot:   bot,
-		Store: store,
+		Bot:         bot,
+		Store:       store,
+		Source:      source,
+		Workers:     workers,
+		Schedule:    schedule,
+		health:      newHealthTracker(),
+		unreachable: newUnreachableNotices(),
+	}
+}
+
+// OnAlert задает обработчик служебных оповещений (поломка разбора, массовые ошибки входа)
+func (c *Checker) OnAlert(fn func(text string)) {
+	c.onAlert = fn
+}
+
+// observeHealth копит результаты цикла и, когда проверены все страницы подписок (полный обход расписания),
+// оценивает качество разбора и отправляет оповещения администратору
+// Прерванный цикл не учитывается: незавершенные загрузки выглядели бы как пустые корты
+func (c *Checker) observeHealth(ctx context.Context, subscriptions []*storage.Subscription, cache *scheduleCache) {
+	if ctx.Err() != nil {
+		return
+	}
+	rotation, complete := c.health.collect(cache.results(), c.unionKeys(subscriptions))
+	if !complete {
+		return
+	}
+	alerts := c.health.observe(rotation)
+	log.Printf("🩺 %s", c.health.summary())
+	for _, text := range alerts {
+		log.Printf("🚨 %s", text)
+		if c.onAlert != nil {
+			c.onAlert(text)
+		}
 	}
 }
 
-// Start запускает горутину для периодической проверки с адаптивным интервалом
-func (c *Checker) Start() {
-	log.Println("🔍 Checker service started")
+// Start запускает горутину для периодической проверки с приоритетом ближних дат
+// Отмена ctx останавливает цикл проверок и прерывает текущие загрузки
+func (c *Checker) Start(ctx context.Context) {
+	log.Printf("🔍 Checker service started (%d workers)", c.Workers)
+	for i := range c.Schedule.Windows {
+		log.Printf("🗓 Poll window %s", &c.Schedule.Windows[i])
+	}
 
 	// Инициализируем кеш для существующих подписок без отправки уведомлений
-	c.initializeExistingSubscriptions()
+	c.initializeExistingSubscriptions(ctx)
 
-	// Адаптивный таймер: 20 минут днем, 3 часа ночью
-	go c.adaptiveCheckLoop()
+	// Приоритетный опрос: ближние даты проверяются чаще дальних, ночью реже (см. PollSchedule, CHECK_SCHEDULE)
+	go c.pollLoop(ctx)
+
+	// Слежения за отменами проверяются отдельно и чаще
+	go c.watchLoop(ctx)
 }
 
 // initializeExistingSubscriptions инициализирует кеш для существующих подписок без отправки уведомлений
-func (c *Checker) initializeExistingSubscriptions() {
+func (c *Checker) initializeExistingSubscriptions(ctx context.Context) {
 	log.Println("🔄 Initializing cache for existing subscriptions...")
 
 	// Получаем все активные подписки
@@ -48,56 +118,50 @@ func (c *Checker) initializeExistingSubscriptions() {
 		return
 	}
 
+	subscriptions = activeSubscriptions(subscriptions, time.Now())
 	log.Printf("📋 Found %d existing subscriptions to initialize", len(subscriptions))
 
+	// Общий кеш графиков: каждая страница загружается один раз для всех подписок
+	cache := newScheduleCache(c.Source)
+	cache.prefetch(ctx, c.unionKeys(subscriptions), c.Workers)
+	c.schedulePolled(subscriptions, cache)
+	c.observeHealth(ctx, subscriptions, cache)
+	c.recordLifecycle(ctx, cache)
+
 	for _, sub := range subscriptions {
 		// Пропускаем неполные подписки
-		if len(sub.Districts) == 0 || len(sub.Courts) == 0 || len(sub.Days) == 0 {
+		if !isComplete(sub) {
 			continue
 		}
 
-		log.Printf("🔄 Initializing cache for chatID: %d", sub.ChatID)
+		log.Printf("🔄 Initializing cache for chatID: %d, subscription %s", sub.ChatID, sub.ID)
 
 		// Собираем все доступные слоты
-		allSlots := c.findAvailableSlots(sub)
+		allSlots, failed := c.findAvailableSlots(ctx, sub, cache)
 
-		// Фильтруем по выбранным кортам
-		filteredSlots := c.filterBySelectedCourts(allSlots, sub.Courts)
+		// Фильтруем по выбранным кортам и убираем прошедшие слоты
+		loc := types.CityLocation(sub.City)
+		filteredSlots := c.filterPastSlots(c.filterBySelectedCourts(allSlots, sub.Courts), loc)
+
+		// Страницы, которые не загрузились, сохраняют прошлое состояние - иначе их слоты
+		// пропали бы из кеша и в первом же цикле пришли бы как новые
+		if len(failed) > 0 {
+			last, _ := c.loadLastSlots(sub)
+			filteredSlots = carryOverFailed(filteredSlots, c.filterPastSlots(last, loc), failed)
+		}
 
 		// Сохраняем в кеш БЕЗ отправки уведомлений
-		c.Store.SaveLastSlots(sub.ChatID, filteredSlots)
+		c.Store.SaveLastSlots(sub.ChatID, sub.ID, filteredSlots)
 
-		log.Printf("  ✅ Cached %d slots for chatID: %d", len(filteredSlots), sub.ChatID)
+		log.Printf("  ✅ Cached %d slots for chatID: %d, subscription %s", len(filteredSlots), sub.ChatID, sub.ID)
 	}
 
 	log.Println("✅ Cache initialization completed")
 }
 
-// adaptiveCheckLoop запускает проверки с адаптивным интервалом
-func (c *Checker) adaptiveCheckLoop() {
-	for {
-		now := time.Now()
-		hour := now.Hour()
-
-		// С 1:00 до 8:00 - проверяем раз в 3 часа
-		// С 8:00 до 1:00 - проверяем каждые 20 минут
-		var sleepDuration time.Duration
-		if hour >= 1 && hour < 8 {
-			sleepDuration = 4 * time.Hour
-			log.Println("😴 Night mode: next check in 3 hours")
-		} else {
-			sleepDuration = 20 * time.Minute
-			log.Println("🔍 Day mode: next check in 20 minutes")
-		}
-
-		time.Sleep(sleepDuration)
-		c.checkAll(false) // Периодическая проверка - только новые слоты
-	}
-}
-
-// checkAll проверяет все подписки
+// checkAll проверяет все подписки по всем страницам графика (полный цикл)
 // isInitial - true при первом запуске (отправляем все слоты), false при периодических проверках (только новые)
-func (c *Checker) checkAll(isInitial bool) {
+func (c *Checker) checkAll(ctx context.Context, isInitial bool) {
 	log.Println("🔍 Running availability check...")
 
 	// Получаем все активные подписки
@@ -107,82 +171,160 @@ func (c *Checker) checkAll(isInitial bool) {
 		return
 	}
 
+	subscriptions = activeSubscriptions(subscriptions, time.Now())
 	log.Printf("📋 Found %d active subscriptions", len(subscriptions))
+	c.runCycle(ctx, subscriptions, c.unionKeys(subscriptions), isInitial)
+}
+
+// runCycle загружает страницы keys и раздает результаты подпискам, которым они нужны
+// Страницы вне keys не загружаются: для подписок они считаются неудачными и сохраняют прошлое состояние
+func (c *Checker) runCycle(ctx context.Context, subscriptions []*storage.Subscription, keys []scheduleKey, isInitial bool) {
+	c.cycleMu.Lock()
+	defer c.cycleMu.Unlock()
+
+	// Сначала загружаем объединение (корт, дата) по всем подпискам - каждую страницу один раз
+	cache := newScheduleCache(c.Source)
+	cache.restrict(keys)
+	log.Printf("📄 Fetching %d unique schedule pages with %d workers", len(keys), c.Workers)
+	start := time.Now()
+	cache.prefetch(ctx, keys, c.Workers)
+	log.Printf("📄 Schedule pages fetched in %s", time.Since(start).Round(time.Second))
+	c.status.record(start, len(keys))
+	c.schedulePolled(subscriptions, cache)
+	c.observeHealth(ctx, subscriptions, cache)
+	c.recordLifecycle(ctx, cache)
+	c.notifyUnreachable(ctx, subscriptions, cache)
+
+	inCycle := make(map[scheduleKey]bool, len(keys))
+	for _, key := range keys {
+		inCycle[key] = true
+	}
 
+	// Затем раздаем результаты по фильтрам каждой подписки, затронутой циклом
 	for _, sub := range subscriptions {
-		c.checkSubscription(sub, isInitial)
+		if ctx.Err() != nil {
+			return
+		}
+		if !c.touchedBy(sub, inCycle) {
+			continue
+		}
+		c.checkSubscription(ctx, sub, isInitial, cache)
+	}
+}
+
+// touchedBy проверяет, загружалась ли в цикле хотя бы одна страница подписки
+func (c *Checker) touchedBy(sub *storage.Subscription, inCycle map[scheduleKey]bool) bool {
+	for _, key := range c.scheduleKeys(sub) {
+		if inCycle[key] {
+			return true
+		}
 	}
+	return false
 }
 
-// checkSubscription проверяет одну подписку
-func (c *Checker) checkSubscription(sub *storage.Subscription, isInitial bool) {
+// checkSubscription проверяет одну подписку, используя общий кеш графиков цикла
+func (c *Checker) checkSubscription(ctx context.Context, sub *storage.Subscription, isInitial bool, cache *scheduleCache) {
 	// Пропускаем неполные подписки
-	if len(sub.Districts) == 0 || len(sub.Courts) == 0 || len(sub.Days) == 0 {
+	if !isComplete(sub) {
 		return
 	}
 
-	log.Printf("🔍 Checking subscription for chatID: %d", sub.ChatID)
+	log.Printf("🔍 Checking subscription %s for chatID: %d", sub.ID, sub.ChatID)
 
 	// Собираем все доступные слоты
-	allSlots := c.findAvailableSlots(sub)
+	allSlots, failed := c.findAvailableSlots(ctx, sub, cache)
 
 	// Фильтруем по выбранным кортам
 	filteredSlots := c.filterBySelectedCourts(allSlots, sub.Courts)
 
 	// Фильтруем слоты, которые уже прошли
-	filteredSlots = c.filterPastSlots(filteredSlots)
+	filteredSlots = c.filterPastSlots(filteredSlots, types.CityLocation(sub.City))
 
 	log.Printf("  → Found %d slots (after filtering by selected courts and removing past slots)", len(filteredSlots))
 
-	if isInitial {
+	// Разовая проверка (/check) не имеет ID - состояние подписок чата не трогаем
+	if sub.ID == "" {
+		c.sendNotification(sub.ChatID, filteredSlots, "🎾 Текущие доступные слоты:")
+		return
+	}
+
+	loc := types.CityLocation(sub.City)
+	last, hasLast := c.loadLastSlots(sub)
+	filteredSlots = carryOverFailed(filteredSlots, c.filterPastSlots(last, loc), failed)
+
+	var sent []sentMessage
+	switch {
+	case isInitial:
 		// Первая проверка - отправляем все доступные слоты
-		if len(filteredSlots) > 0 {
-			c.sendNotification(sub.ChatID, filteredSlots, "🎾 Текущие доступные слоты:")
-		}
-		// Сохраняем состояние
-		c.Store.SaveLastSlots(sub.ChatID, filteredSlots)
-	} else {
-		// Периодическая проверка - только новые слоты
-		newSlots := c.findNewSlots(sub.ChatID, filteredSlots)
-		if len(newSlots) > 0 {
-			c.sendNotification(sub.ChatID, newSlots, "🆕 Появились новые слоты!")
-			// Обновляем состояние
-			c.Store.SaveLastSlots(sub.ChatID, filteredSlots)
-		}
+		sent = c.sendNotification(sub.ChatID, filteredSlots, subHeader(sub, "🎾 Текущие доступные слоты:"))
+	case !hasLast:
+		// Состояние подписки потеряно - по жизненному циклу сообщаем только о действительно новых слотах
+		sent = c.sendNotification(sub.ChatID, c.freshSlots(filteredSlots, cache), subHeader(sub, "🆕 Появились новые слоты!"))
+	default:
+		// Периодическая проверка - полная разница с прошлым состоянием
+		diff := diffSlots(last, filteredSlots)
+		log.Printf("  → Diff: +%d -%d ~%d", len(diff.Appeared), len(diff.Disappeared), len(diff.Changed))
+
+		sent = c.sendNotification(sub.ChatID, diff.Appeared, subHeader(sub, "🆕 Появились новые слоты!"))
+		sent = append(sent, c.sendNotification(sub.ChatID, diff.Changed, subHeader(sub, "🔄 Изменились слоты:"))...)
+
+		// Прошедшие слоты пропадают естественным образом - их не зачеркиваем
+		c.markGone(sub, c.filterPastSlots(diff.Disappeared, loc))
 	}
+	c.rememberSentMessages(sub, loc, sent)
+
+	// Состояние сохраняем всегда, чтобы следующая разница считалась от актуальных данных
+	c.Store.SaveLastSlots(sub.ChatID, sub.ID, filteredSlots)
 }
 
-// CheckSubscriptionNow проверяет конкретную подписку сразу (для использования после создания подписки)
-func (c *Checker) CheckSubscriptionNow(chatID int64) {
-	sub, err := c.Store.GetCurrent(chatID)
-	if err != nil || sub == nil {
-		log.Printf("⚠️ Error fetching subscription for chatID %d: %v", chatID, err)
-		return
+// subHeader добавляет к заголовку уведомления название подписки - у чата их может быть несколько
+func subHeader(sub *storage.Subscription, header string) string {
+	if sub.Name == "" {
+		return header
 	}
+	return "📌 " + sub.Name + "\n" + header
+}
 
+// CheckSubscriptionNow проверяет конкретную подписку сразу (после создания подписки, /get_current или /check)
+func (c *Checker) CheckSubscriptionNow(sub *storage.Subscription) {
 	// Запускаем проверку в отдельной горутине, чтобы не блокировать ответ пользователю
-	go c.checkSubscription(sub, true)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), checkNowTimeout)
+		defer cancel()
+
+		// Как и циклы опроса, сравнивает слоты с прошлым состоянием и сохраняет новое - не пересекаемся с ними
+		c.cycleMu.Lock()
+		defer c.cycleMu.Unlock()
+
+		cache := newScheduleCache(c.Source)
+		cache.prefetch(ctx, c.scheduleKeys(sub), c.Workers)
+		c.recordLifecycle(ctx, cache)
+		c.checkSubscription(ctx, sub, true, cache)
+	}()
 }
 
 // findAvailableSlots ищет все доступные слоты для подписки
-func (c *Checker) findAvailableSlots(sub *storage.Subscription) []types.Slot {
+// failed - страницы графика, которые не удалось загрузить в этом цикле (их слоты неизвестны)
+func (c *Checker) findAvailableSlots(ctx context.Context, sub *storage.Subscription, cache *scheduleCache) ([]types.Slot, map[scheduleKey]bool) {
 	allSlots := make([]types.Slot, 0)
+	failed := make(map[scheduleKey]bool)
 
-	// Генерируем даты на 14 дней вперед для выбранных дней недели
-	dates := c.generateDates(sub.Days, 14)
-
-	// Для каждого корта
-	for _, courtID := range sub.Courts {
-		// Для каждой даты
-		for _, date := range dates {
-			// Один запрос на корт на день - получаем весь график
-			slots, err := parser.CheckCourtSchedule(courtID, date, sub.TimeFrom, sub.TimeTo)
-			if err != nil {
-				log.Printf("⚠️ Error checking schedule for %s on %s: %v", courtID, date, err)
-				continue
+	// Для каждой пары (корт, дата) - один запрос на весь график (или результат из кеша цикла)
+	for _, key := range c.scheduleKeys(sub) {
+		slots, err := cache.get(ctx, key)
+		if err != nil {
+			if !errors.Is(err, errNotDue) {
+				log.Printf("⚠️ Error checking schedule for %s on %s: %v", key.CourtID, key.Date, err)
 			}
-			allSlots = append(allSlots, slots...)
+			failed[key] = true
+			continue
 		}
+		slots = c.filterByWindows(slots, sub.WindowsFor(weekdayOf(key.Date)))
+		slots = c.filterByEnvironment(slots, sub.Environments)
+		slots = c.filterByPrice(slots, sub.MaxPrice)
+		slots = c.mergeConsecutive(slots)
+		slots = c.filterByMinDuration(slots, sub.MinDuration)
+		allSlots = append(allSlots, slots...)
 	}
 
 	// Дедупликация по UniqueID
@@ -196,13 +338,28 @@ func (c *Checker) findAvailableSlots(sub *storage.Subscription) []types.Slot {
 		}
 	}
 
-	return uniqueSlots
+	return uniqueSlots, failed
+}
+
+// carryOverFailed добавляет к текущим слотам прошлые слоты страниц, которые не загрузились в этом цикле,
+// чтобы сбой загрузки не выглядел как исчезновение слотов (и их повторное появление в следующем цикле)
+func carryOverFailed(current, last []types.Slot, failed map[scheduleKey]bool) []types.Slot {
+	if len(failed) == 0 {
+		return current
+	}
+	for _, slot := range last {
+		key := scheduleKey{Sport: types.SportOrDefault(slot.Sport), CourtID: slot.ClubID, Date: slot.Date}
+		if failed[key] {
+			current = append(current, slot)
+		}
+	}
+	return current
 }
 
-// generateDates генерирует даты на следующие N дней для выбранных дней недели
-func (c *Checker) generateDates(selectedDays []string, daysAhead int) []string {
+// generateDates генерирует даты на следующие N дней для выбранных дней недели (по местному времени города)
+func (c *Checker) generateDates(selectedDays []string, daysAhead int, loc *time.Location) []string {
 	dates := make([]string, 0)
-	now := time.Now()
+	now := time.Now().In(loc)
 
 	// Проверяем каждый день в периоде
 	for i := 0; i < daysAhead; i++ {
@@ -221,30 +378,152 @@ func (c *Checker) generateDates(selectedDays []string, daysAhead int) []string {
 	return dates
 }
 
-// generateTimeSlots генерирует временные слоты каждые 30 минут между from и to
-func (c *Checker) generateTimeSlots(from, to string) []string {
-	slots := make([]string, 0)
+// upcomingDates оставляет из конкретных дат подписки те, что попадают в ближайшие days дней (включая сегодня), по порядку
+// Более дальние даты начинают проверяться, когда до них останется days дней, - как и даты по дням недели
+func upcomingDates(dates []string, now time.Time, days int) []string {
+	today := now.Format("2006-01-02")
+	end := now.AddDate(0, 0, days).Format("2006-01-02")
+	upcoming := make([]string, 0, len(dates))
+	for _, date := range dates {
+		if date >= today && date < end {
+			upcoming = append(upcoming, date)
+		}
+	}
+	sort.Strings(upcoming)
+	return upcoming
+}
+
+// filterByWindows оставляет слоты, попадающие хотя бы в одно окно времени дня
+func (c *Checker) filterByWindows(slots []types.Slot, windows []storage.TimeWindow) []types.Slot {
+	filtered := make([]types.Slot, 0)
+	for _, slot := range slots {
+		for _, w := range windows {
+			if slot.Time >= w.From && slot.Time <= w.To {
+				filtered = append(filtered, slot)
+				break
+			}
+		}
+	}
+	return filtered
+}
 
-	// Парсим from и to
-	fromTime, err := time.Parse("15:04", from)
+// weekdayOf возвращает короткое название дня недели даты ("2025-11-08" -> "Sat")
+func weekdayOf(date string) string {
+	d, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		log.Printf("⚠️ Error parsing TimeFrom: %v", err)
+		return ""
+	}
+	return d.Weekday().String()[:3]
+}
+
+// filterByEnvironment оставляет слоты на кортах выбранных типов (пустой список - крытые и балоны)
+func (c *Checker) filterByEnvironment(slots []types.Slot, environments []string) []types.Slot {
+	if len(environments) == 0 {
+		environments = types.DefaultEnvironments
+	}
+
+	allowed := make(map[string]bool)
+	for _, env := range environments {
+		allowed[env] = true
+	}
+
+	filtered := make([]types.Slot, 0)
+	for _, slot := range slots {
+		// Слоты без классификации (сохраненные до появления поля) считаем крытыми
+		env := slot.Environment
+		if env == "" {
+			env = types.EnvIndoor
+		}
+		if allowed[env] {
+			filtered = append(filtered, slot)
+		}
+	}
+	return filtered
+}
+
+// filterByPrice отбрасывает слоты дороже maxPrice PLN за час (0 - без ограничения)
+// Слоты без известной цены пропускаем, чтобы не потерять их
+func (c *Checker) filterByPrice(slots []types.Slot, maxPrice int) []types.Slot {
+	if maxPrice <= 0 {
 		return slots
 	}
-	toTime, err := time.Parse("15:04", to)
-	if err != nil {
-		log.Printf("⚠️ Error parsing TimeTo: %v", err)
+
+	filtered := make([]types.Slot, 0)
+	for _, slot := range slots {
+		if slot.Price.IsZero() || slot.Price.Minor <= int64(maxPrice)*100 {
+			filtered = append(filtered, slot)
+		}
+	}
+	return filtered
+}
+
+// mergeConsecutive объединяет соседние свободные ячейки одного корта в непрерывные блоки
+// 18:00, 18:30, 19:00, 19:30 по 30 минут -> 18:00 на 120 минут
+func (c *Checker) mergeConsecutive(slots []types.Slot) []types.Slot {
+	if len(slots) == 0 {
 		return slots
 	}
 
-	// Генерируем слоты каждые 30 минут
-	current := fromTime
-	for current.Before(toTime) {
-		slots = append(slots, current.Format("15:04"))
-		current = current.Add(30 * time.Minute)
+	sorted := make([]types.Slot, len(slots))
+	copy(sorted, slots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.ClubID != b.ClubID {
+			return a.ClubID < b.ClubID
+		}
+		if a.CourtType != b.CourtType {
+			return a.CourtType < b.CourtType
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.Time < b.Time
+	})
+
+	merged := make([]types.Slot, 0, len(sorted))
+	// Стоимость блока и минуты ячеек с известной ценой: цена блока - средняя за час по его ячейкам
+	cost := make([]int64, 0, len(sorted))
+	priced := make([]int, 0, len(sorted))
+	for _, slot := range sorted {
+		slotCost, slotPriced := int64(0), 0
+		if !slot.Price.IsZero() {
+			slotCost, slotPriced = slot.Price.Minor*int64(slot.Duration), slot.Duration
+		}
+
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Sport == slot.Sport && last.ClubID == slot.ClubID && last.CourtType == slot.CourtType &&
+				last.Date == slot.Date && last.EndTime() == slot.Time {
+				last.Duration += slot.Duration
+				cost[n-1] += slotCost
+				priced[n-1] += slotPriced
+				if priced[n-1] > 0 {
+					last.Price = types.Money{Minor: (cost[n-1] + int64(priced[n-1])/2) / int64(priced[n-1]), Currency: "PLN"}
+				}
+				continue
+			}
+		}
+		merged = append(merged, slot)
+		cost = append(cost, slotCost)
+		priced = append(priced, slotPriced)
+	}
+
+	return merged
+}
+
+// filterByMinDuration оставляет блоки не короче minDuration минут (0 - любые)
+func (c *Checker) filterByMinDuration(slots []types.Slot, minDuration int) []types.Slot {
+	if minDuration <= 0 {
+		return slots
 	}
 
-	return slots
+	filtered := make([]types.Slot, 0)
+	for _, slot := range slots {
+		if slot.Duration >= minDuration {
+			filtered = append(filtered, slot)
+		}
+	}
+	return filtered
 }
 
 // filterBySelectedCourts фильтрует слоты по выбранным кортам
@@ -266,14 +545,14 @@ func (c *Checker) filterBySelectedCourts(slots []types.Slot, selectedCourts []st
 	return filtered
 }
 
-// filterPastSlots фильтрует слоты, которые уже прошли
-func (c *Checker) filterPastSlots(slots []types.Slot) []types.Slot {
+// filterPastSlots фильтрует слоты, которые уже прошли (время слотов - местное время города)
+func (c *Checker) filterPastSlots(slots []types.Slot, loc *time.Location) []types.Slot {
 	filtered := make([]types.Slot, 0)
 	now := time.Now()
 
 	for _, slot := range slots {
 		// Парсим дату и время слота
-		slotDateTime, err := time.Parse("2006-01-02 15:04", slot.Date+" "+slot.Time)
+		slotDateTime, err := time.ParseInLocation("2006-01-02 15:04", slot.Date+" "+slot.Time, loc)
 		if err != nil {
 			log.Printf("⚠️ Error parsing slot date/time: %v (date=%s, time=%s)", err, slot.Date, slot.Time)
 			continue
@@ -288,70 +567,37 @@ func (c *Checker) filterPastSlots(slots []types.Slot) []types.Slot {
 	return filtered
 }
 
-// findNewSlots находит новые слоты (которых не было в предыдущей проверке)
-func (c *Checker) findNewSlots(chatID int64, currentSlots []types.Slot) []types.Slot {
-	// Загружаем предыдущие слоты
-	lastSlotsData, err := c.Store.GetLastSlots(chatID)
-	if err != nil || lastSlotsData == nil {
-		// Если нет предыдущих данных, считаем все слоты новыми
-		return currentSlots
-	}
-
-	var lastSlots []types.Slot
-	if err := json.Unmarshal(lastSlotsData, &lastSlots); err != nil {
-		log.Printf("⚠️ Error unmarshaling last slots: %v", err)
-		return currentSlots
-	}
-
-	// Создаем мапу предыдущих слотов
-	lastSlotsMap := make(map[string]bool)
-	for _, slot := range lastSlots {
-		lastSlotsMap[slot.UniqueID()] = true
-	}
-
-	// Находим новые слоты
-	newSlots := make([]types.Slot, 0)
-	for _, slot := range currentSlots {
-		if !lastSlotsMap[slot.UniqueID()] {
-			newSlots = append(newSlots, slot)
-		}
-	}
-
-	return newSlots
-}
-
-// sendNotification отправляет уведомление о доступных слотах
-func (c *Checker) sendNotification(chatID int64, slots []types.Slot, header string) {
+// sendNotification отправляет уведомление о доступных слотах (отдельное сообщение на каждый клуб)
+// Возвращает отправленные сообщения, чтобы позже можно было зачеркнуть пропавшие слоты
+func (c *Checker) sendNotification(chatID int64, slots []types.Slot, header string) []sentMessage {
 	if len(slots) == 0 {
-		return
+		return nil
 	}
 
 	// Группируем слоты по клубам для более читабельного вывода
 	clubSlots := make(map[string][]types.Slot)
+	clubs := make([]string, 0)
 	for _, slot := range slots {
+		if _, ok := clubSlots[slot.ClubName]; !ok {
+			clubs = append(clubs, slot.ClubName)
+		}
 		clubSlots[slot.ClubName] = append(clubSlots[slot.ClubName], slot)
 	}
 
-	// Формируем сообщение
-	// Отправляем отдельное сообщение для каждого клуба
-	for clubName, clubSlotsList := range clubSlots {
-		var message strings.Builder
-		message.WriteString(fmt.Sprintf("🎾 **%s**\n\n", clubName))
+	sent := make([]sentMessage, 0, len(clubs))
+	for _, clubName := range clubs {
+		clubSlotsList := clubSlots[clubName]
 
-		for _, slot := range clubSlotsList {
-			// Название корта уже очищено в парсере (cleanCourtName)
-			courtName := strings.TrimSpace(slot.CourtType)
-
-			message.WriteString(fmt.Sprintf(
-				"%s %s - %s\n",
-				slot.Date, slot.Time, courtName,
-			))
+		msg := tgbotapi.NewMessage(chatID, formatClubMessage(header, clubName, clubSlotsList, nil))
+		msg.ParseMode = "HTML"
+		resp, err := c.Bot.Send(msg)
+		if err != nil {
+			log.Printf("⚠️ Failed to send notification to %d: %v", chatID, err)
+			continue
 		}
-
-		msg := tgbotapi.NewMessage(chatID, message.String())
-		msg.ParseMode = "Markdown"
-		c.Bot.Send(msg)
+		sent = append(sent, sentMessage{MessageID: resp.MessageID, Header: header, ClubName: clubName, Slots: clubSlotsList})
 	}
 
 	log.Printf("✅ Notification sent to chatID: %d (%d slots)", chatID, len(slots))
+	return sent
 }
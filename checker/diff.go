@@ -0,0 +1,117 @@
+package checker
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+)
+
+// slotDiff - разница между предыдущим и текущим состоянием слотов подписки
+// Слоты - блоки подряд идущих свободных ячеек (см. mergeConsecutive), поэтому сравниваются
+// не по началу блока, а по свободному времени на корте: бронь первой ячейки сдвигает начало блока,
+// но новым он от этого не становится
+type slotDiff struct {
+	Appeared    []types.Slot // блоки, не пересекающиеся ни с одним прошлым блоком корта
+	Disappeared []types.Slot // прошлые блоки, от которых не осталось ни минуты (забронированы или сняты)
+	Changed     []types.Slot // блоки, в которых освободилось новое время, или тот же блок с другой ценой
+}
+
+// courtDay - корт в конкретный день: внутри него сравнивается свободное время
+type courtDay struct {
+	Sport, ClubID, CourtType, Date string
+}
+
+func courtDayOf(slot types.Slot) courtDay {
+	return courtDay{types.SportOrDefault(slot.Sport), slot.ClubID, slot.CourtType, slot.Date}
+}
+
+// slotSpan возвращает начало и конец слота в минутах от начала дня
+func slotSpan(slot types.Slot) (int, int, bool) {
+	start, err := time.Parse("15:04", slot.Time)
+	if err != nil {
+		return 0, 0, false
+	}
+	from := start.Hour()*60 + start.Minute()
+	return from, from + slot.Duration, true
+}
+
+// diffSlots сравнивает свободное время на кортах: уведомлять стоит только о новых свободных ячейках
+func diffSlots(last, current []types.Slot) slotDiff {
+	var d slotDiff
+
+	lastByCourt := make(map[courtDay][]types.Slot)
+	lastByID := make(map[string]types.Slot, len(last))
+	for _, slot := range last {
+		lastByCourt[courtDayOf(slot)] = append(lastByCourt[courtDayOf(slot)], slot)
+		lastByID[slot.UniqueID()] = slot
+	}
+
+	currentByCourt := make(map[courtDay][]types.Slot)
+	for _, slot := range current {
+		currentByCourt[courtDayOf(slot)] = append(currentByCourt[courtDayOf(slot)], slot)
+
+		prev := lastByCourt[courtDayOf(slot)]
+		overlapping, uncovered := coverage(slot, prev)
+		switch {
+		case overlapping == 0:
+			d.Appeared = append(d.Appeared, slot)
+		case uncovered > 0:
+			d.Changed = append(d.Changed, slot)
+		default:
+			if p, ok := lastByID[slot.UniqueID()]; ok && p.Duration == slot.Duration && p.Price != slot.Price {
+				d.Changed = append(d.Changed, slot)
+			}
+		}
+	}
+
+	for _, slot := range last {
+		if overlapping, _ := coverage(slot, currentByCourt[courtDayOf(slot)]); overlapping == 0 {
+			d.Disappeared = append(d.Disappeared, slot)
+		}
+	}
+	return d
+}
+
+// coverage считает, сколько минут слота пересекается со слотами others того же корта
+// и сколько минут слота ими не покрыто (others внутри дня не пересекаются друг с другом)
+func coverage(slot types.Slot, others []types.Slot) (overlapping, uncovered int) {
+	from, to, ok := slotSpan(slot)
+	if !ok {
+		// Время не разобрать - сравниваем как раньше, по началу
+		for _, o := range others {
+			if o.UniqueID() == slot.UniqueID() {
+				return slot.Duration, 0
+			}
+		}
+		return 0, slot.Duration
+	}
+
+	for _, o := range others {
+		oFrom, oTo, ok := slotSpan(o)
+		if !ok {
+			continue
+		}
+		if lo, hi := max(from, oFrom), min(to, oTo); hi > lo {
+			overlapping += hi - lo
+		}
+	}
+	return overlapping, to - from - overlapping
+}
+
+// loadLastSlots загружает сохраненное состояние подписки (ok=false если состояния нет)
+func (c *Checker) loadLastSlots(sub *storage.Subscription) ([]types.Slot, bool) {
+	data, err := c.Store.GetLastSlots(sub.ChatID, sub.ID)
+	if err != nil || data == nil {
+		return nil, false
+	}
+
+	var slots []types.Slot
+	if err := json.Unmarshal(data, &slots); err != nil {
+		log.Printf("⚠️ Error unmarshaling last slots: %v", err)
+		return nil, false
+	}
+	return slots, true
+}
@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"court-bot/types"
+)
+
+// block - слот корта "Kort 1" клуба klub на 2025-11-05
+func block(start string, duration int) types.Slot {
+	return types.Slot{Sport: "tenis", ClubID: "klub", ClubName: "Klub", CourtType: "Kort 1", TypeID: "klub", Date: "2025-11-05", Time: start, Duration: duration}
+}
+
+// times описывает слоты для сравнения: "18:00+120 19:00+60"
+func times(slots []types.Slot) string {
+	parts := make([]string, len(slots))
+	for i, s := range slots {
+		parts[i] = s.Time + "+" + strconv.Itoa(s.Duration)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestDiffSlotsCellLevel(t *testing.T) {
+	tests := []struct {
+		name                           string
+		last, current                  []types.Slot
+		appeared, disappeared, changed string
+	}{
+		{
+			name:    "first cell of a block booked",
+			last:    []types.Slot{block("18:00", 120)},
+			current: []types.Slot{block("18:30", 90)},
+		},
+		{
+			name:    "block grows at the end",
+			last:    []types.Slot{block("18:00", 60)},
+			current: []types.Slot{block("18:00", 90)},
+			changed: "18:00+90",
+		},
+		{
+			name:    "gap freed joins two blocks",
+			last:    []types.Slot{block("18:00", 60), block("19:30", 60)},
+			current: []types.Slot{block("18:00", 150)},
+			changed: "18:00+150",
+		},
+		{
+			name:        "separate new block and fully booked block",
+			last:        []types.Slot{block("08:00", 60), block("18:00", 60)},
+			current:     []types.Slot{block("18:00", 60), block("21:00", 30)},
+			appeared:    "21:00+30",
+			disappeared: "08:00+60",
+		},
+		{
+			name:    "middle booked splits a block",
+			last:    []types.Slot{block("18:00", 120)},
+			current: []types.Slot{block("18:00", 30), block("19:30", 30)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffSlots(tt.last, tt.current)
+			if got := times(d.Appeared); got != tt.appeared {
+				t.Errorf("appeared = %q, want %q", got, tt.appeared)
+			}
+			if got := times(d.Disappeared); got != tt.disappeared {
+				t.Errorf("disappeared = %q, want %q", got, tt.disappeared)
+			}
+			if got := times(d.Changed); got != tt.changed {
+				t.Errorf("changed = %q, want %q", got, tt.changed)
+			}
+		})
+	}
+}
+
+func TestDiffSlots(t *testing.T) {
+	priced := func(s types.Slot, pln int64) types.Slot {
+		s.Price = types.Money{Minor: pln * 100, Currency: "PLN"}
+		return s
+	}
+	otherCourt := block("18:00", 60)
+	otherCourt.CourtType = "Kort 2"
+	otherDate := block("18:00", 60)
+	otherDate.Date = "2025-11-06"
+
+	tests := []struct {
+		name                           string
+		last, current                  []types.Slot
+		appeared, disappeared, changed string
+	}{
+		{
+			name:    "nothing changed",
+			last:    []types.Slot{block("18:00", 60), block("20:00", 60)},
+			current: []types.Slot{block("18:00", 60), block("20:00", 60)},
+		},
+		{
+			name:     "no previous state",
+			current:  []types.Slot{block("18:00", 60)},
+			appeared: "18:00+60",
+		},
+		{
+			name:        "all booked",
+			last:        []types.Slot{block("18:00", 60)},
+			disappeared: "18:00+60",
+		},
+		{
+			name:     "same time on another court is new",
+			last:     []types.Slot{block("18:00", 60)},
+			current:  []types.Slot{block("18:00", 60), otherCourt},
+			appeared: "18:00+60",
+		},
+		{
+			name:        "same time on another date is new",
+			last:        []types.Slot{block("18:00", 60)},
+			current:     []types.Slot{otherDate},
+			appeared:    "18:00+60",
+			disappeared: "18:00+60",
+		},
+		{
+			name:    "price changed",
+			last:    []types.Slot{priced(block("18:00", 60), 80)},
+			current: []types.Slot{priced(block("18:00", 60), 60)},
+			changed: "18:00+60",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffSlots(tt.last, tt.current)
+			if got := times(d.Appeared); got != tt.appeared {
+				t.Errorf("appeared = %q, want %q", got, tt.appeared)
+			}
+			if got := times(d.Disappeared); got != tt.disappeared {
+				t.Errorf("disappeared = %q, want %q", got, tt.disappeared)
+			}
+			if got := times(d.Changed); got != tt.changed {
+				t.Errorf("changed = %q, want %q", got, tt.changed)
+			}
+		})
+	}
+}
+
+func TestCarryOverFailed(t *testing.T) {
+	otherDate := block("20:00", 60)
+	otherDate.Date = "2025-11-06"
+	last := []types.Slot{block("18:00", 60), otherDate}
+	current := []types.Slot{block("19:00", 60)}
+
+	if got := carryOverFailed(current, last, nil); times(got) != "19:00+60" {
+		t.Errorf("without failed pages = %q, want current slots only", times(got))
+	}
+
+	// Страница 2025-11-06 не загрузилась - ее слоты берутся из прошлого состояния
+	failed := map[scheduleKey]bool{{Sport: "tenis", CourtID: "klub", Date: "2025-11-06"}: true}
+	if got, want := times(carryOverFailed(current, last, failed)), "19:00+60 20:00+60"; got != want {
+		t.Errorf("with failed page = %q, want %q", got, want)
+	}
+}
+
+func TestMergeConsecutive(t *testing.T) {
+	priced := func(s types.Slot, pln int64) types.Slot {
+		s.Price = types.Money{Minor: pln * 100, Currency: "PLN"}
+		return s
+	}
+	other := block("18:30", 30)
+	other.CourtType = "Kort 2"
+
+	c := &Checker{}
+	got := c.mergeConsecutive([]types.Slot{
+		block("19:00", 30), block("18:00", 30), block("18:30", 30), // порядок не важен
+		other,
+		block("20:00", 60), // после паузы 19:30-20:00 - отдельный блок
+	})
+	if want := "18:00+90 20:00+60 18:30+30"; times(got) != want {
+		t.Errorf("merged = %q, want %q", times(got), want)
+	}
+
+	// Цена блока - средняя за час по ячейкам с известной ценой
+	got = c.mergeConsecutive([]types.Slot{priced(block("18:00", 60), 80), priced(block("19:00", 30), 100), block("19:30", 30)})
+	if len(got) != 1 || got[0].Duration != 120 {
+		t.Fatalf("merged = %q, want one 120-minute block", times(got))
+	}
+	if want := int64(8667); got[0].Price.Minor != want {
+		t.Errorf("block price = %d, want %d", got[0].Price.Minor, want)
+	}
+}
@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// expiryReminderBefore - за сколько до окончания срока подписки напомнить о нем
+const expiryReminderBefore = 24 * time.Hour
+
+// activeSubscriptions отбрасывает подписки на паузе и с истекшим сроком - их страницы не загружаются
+func activeSubscriptions(subscriptions []*storage.Subscription, now time.Time) []*storage.Subscription {
+	active := make([]*storage.Subscription, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		if sub.IsPaused(now) || sub.IsExpired(now) {
+			continue
+		}
+		active = append(active, sub)
+	}
+	return active
+}
+
+// updateLifetimes снимает закончившиеся паузы, напоминает о скором окончании срока
+// и сообщает о закончившихся подписках (каждое сообщение - один раз)
+func (c *Checker) updateLifetimes(subscriptions []*storage.Subscription, now time.Time) {
+	for _, sub := range subscriptions {
+		if lifetimeNotice(sub, now) == "" {
+			continue
+		}
+
+		// Решение принимается заново на свежей копии: пользователь мог продлить срок или снять паузу,
+		// пока шел цикл, - остальные поля подписки не перезаписываются
+		var text string
+		updated, err := c.Store.UpdateSubscription(sub.ChatID, sub.ID, func(fresh *storage.Subscription) bool {
+			text = lifetimeNotice(fresh, now)
+			return text != ""
+		})
+		if err != nil {
+			log.Printf("⚠️ Error saving subscription %s for %d: %v", sub.ID, sub.ChatID, err)
+			continue
+		}
+		if updated == nil {
+			continue
+		}
+		if _, err := c.Bot.Send(tgbotapi.NewMessage(sub.ChatID, text)); err != nil {
+			log.Printf("⚠️ Failed to send lifetime notice to %d: %v", sub.ChatID, err)
+		}
+	}
+}
+
+// lifetimeNotice отмечает в подписке очередное событие срока или паузы и возвращает текст сообщения о нем
+// Меняются только поля паузы и напоминаний; "" - сообщать не о чем
+func lifetimeNotice(sub *storage.Subscription, now time.Time) string {
+	switch {
+	case sub.Paused && !sub.IsPaused(now):
+		sub.Paused = false
+		sub.PausedUntil = time.Time{}
+		return fmt.Sprintf("▶️ Пауза закончилась — подписка «%s» снова активна.", sub.DisplayName())
+	case sub.DatesPassed(now) && !sub.ExpiryNotified:
+		sub.ExpiryNotified = true
+		return fmt.Sprintf("📅 Все даты подписки «%s» прошли — больше ее не проверяю.\n\nНастройки сохранены: выбрать новые даты можно в /my_subs.", sub.DisplayName())
+	case sub.IsExpired(now) && !sub.ExpiryNotified:
+		sub.ExpiryNotified = true
+		return fmt.Sprintf("⌛ Срок подписки «%s» закончился — больше ее не проверяю.\n\nНастройки сохранены: продлить можно в /my_subs.", sub.DisplayName())
+	case !sub.ExpiresAt.IsZero() && !sub.IsExpired(now) && !sub.ExpiryReminded && sub.ExpiresAt.Sub(now) <= expiryReminderBefore:
+		sub.ExpiryReminded = true
+		at := sub.ExpiresAt.In(types.CityLocation(sub.City))
+		return fmt.Sprintf("⏳ Подписка «%s» закончится %s.\n\nПродлить можно в /my_subs (кнопка «⌛ Срок»).", sub.DisplayName(), at.Format("02.01 в 15:04"))
+	}
+	return ""
+}
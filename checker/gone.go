@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxSentMessages - сколько последних уведомлений на чат помним для последующего редактирования
+const maxSentMessages = 50
+
+// sentMessage - отправленное уведомление о слотах одного клуба
+// Хранится, чтобы при исчезновении слота отредактировать сообщение и зачеркнуть его
+type sentMessage struct {
+	MessageID int
+	Header    string
+	ClubName  string
+	Slots     []types.Slot
+	Gone      []string // UniqueID слотов, которые уже пропали
+}
+
+// formatClubMessage форматирует уведомление по одному клубу (HTML), пропавшие слоты зачеркнуты
+func formatClubMessage(header, clubName string, slots []types.Slot, gone map[string]bool) string {
+	var message strings.Builder
+	if header != "" {
+		message.WriteString(html.EscapeString(header) + "\n\n")
+	}
+	message.WriteString(fmt.Sprintf("🎾 <b>%s</b>\n\n", html.EscapeString(clubName)))
+
+	for _, slot := range slots {
+		// Название корта уже очищено в парсере (cleanCourtName)
+		courtName := strings.TrimSpace(slot.CourtType)
+
+		line := fmt.Sprintf("%s %s–%s - %s", slot.Date, slot.Time, slot.EndTime(), courtName)
+		if !slot.Price.IsZero() {
+			line += fmt.Sprintf(" · %s/ч", slot.Price)
+		}
+		line = html.EscapeString(line)
+		if gone[slot.UniqueID()] {
+			line = "<s>" + line + "</s> ❌"
+		}
+		message.WriteString(line + "\n")
+	}
+	return message.String()
+}
+
+// loadSentMessages загружает уведомления подписки, которые еще можно отредактировать
+func (c *Checker) loadSentMessages(sub *storage.Subscription) []sentMessage {
+	data, err := c.Store.GetSentMessages(sub.ChatID, sub.ID)
+	if err != nil || data == nil {
+		return nil
+	}
+	var msgs []sentMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		log.Printf("⚠️ Error unmarshaling sent messages: %v", err)
+		return nil
+	}
+	return msgs
+}
+
+// rememberSentMessages добавляет новые уведомления, отбрасывая прошедшие и самые старые
+func (c *Checker) rememberSentMessages(sub *storage.Subscription, loc *time.Location, sent []sentMessage) {
+	if len(sent) == 0 {
+		return
+	}
+	msgs := append(c.loadSentMessages(sub), sent...)
+
+	today := time.Now().In(loc).Format("2006-01-02")
+	kept := make([]sentMessage, 0, len(msgs))
+	for _, m := range msgs {
+		if lastSlotDate(m.Slots) >= today {
+			kept = append(kept, m)
+		}
+	}
+	if len(kept) > maxSentMessages {
+		kept = kept[len(kept)-maxSentMessages:]
+	}
+
+	if err := c.Store.SaveSentMessages(sub.ChatID, sub.ID, kept); err != nil {
+		log.Printf("⚠️ Failed to save sent messages for %d: %v", sub.ChatID, err)
+	}
+}
+
+// markGone зачеркивает пропавшие слоты в ранее отправленных уведомлениях (если подписчик это включил)
+// Новых сообщений не отправляет: слоты, о которых уведомления нет, пропускаются
+func (c *Checker) markGone(sub *storage.Subscription, disappeared []types.Slot) {
+	if !sub.NotifyGone || len(disappeared) == 0 {
+		return
+	}
+
+	goneIDs := make(map[string]bool, len(disappeared))
+	for _, slot := range disappeared {
+		goneIDs[slot.UniqueID()] = true
+	}
+
+	msgs := c.loadSentMessages(sub)
+	changed := false
+	for i := range msgs {
+		m := &msgs[i]
+		gone := make(map[string]bool, len(m.Gone))
+		for _, id := range m.Gone {
+			gone[id] = true
+		}
+
+		updated := false
+		for _, slot := range m.Slots {
+			id := slot.UniqueID()
+			if goneIDs[id] && !gone[id] {
+				gone[id] = true
+				m.Gone = append(m.Gone, id)
+				updated = true
+			}
+		}
+		if !updated {
+			continue
+		}
+		changed = true
+
+		edit := tgbotapi.NewEditMessageText(sub.ChatID, m.MessageID, formatClubMessage(m.Header, m.ClubName, m.Slots, gone))
+		edit.ParseMode = "HTML"
+		if _, err := c.Bot.Send(edit); err != nil {
+			log.Printf("⚠️ Failed to strike gone slots in message %d for %d: %v", m.MessageID, sub.ChatID, err)
+		}
+	}
+
+	if changed {
+		if err := c.Store.SaveSentMessages(sub.ChatID, sub.ID, msgs); err != nil {
+			log.Printf("⚠️ Failed to save sent messages for %d: %v", sub.ChatID, err)
+		}
+		log.Printf("❌ Marked %d gone slots for chatID: %d", len(disappeared), sub.ChatID)
+	}
+}
+
+func lastSlotDate(slots []types.Slot) string {
+	last := ""
+	for _, slot := range slots {
+		if slot.Date > last {
+			last = slot.Date
+		}
+	}
+	return last
+}
@@ -0,0 +1,209 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"court-bot/parser"
+)
+
+const (
+	// layoutAlertCycles - сколько обходов расписания подряд клуб должен не разбираться, прежде чем тревожить администратора
+	layoutAlertCycles = 3
+	// healthAlertInterval - не чаще одного общего оповещения одного вида за этот период
+	healthAlertInterval = 6 * time.Hour
+	// emptyAlertMinCourts - общую тревогу "пусто почти везде" поднимаем только при достаточной выборке
+	emptyAlertMinCourts = 5
+	// emptyAlertRatio - доля кортов без слотов, при которой обход считается подозрительным
+	emptyAlertRatio = 0.8
+	// emptyHealthyRatio - доля пустых кортов в предыдущем обходе, который считаем нормальным
+	emptyHealthyRatio = 0.5
+)
+
+// courtHealth - состояние разбора одного клуба (по всем датам обхода)
+type courtHealth struct {
+	Failures  int       // обходов подряд с ErrLayoutChanged
+	LastErr   error     // последняя ошибка разбора
+	LastOK    time.Time // последний успешный разбор
+	LastSlots int       // свободных слотов в последнем обходе
+	Alerted   bool      // администратору уже сообщили о поломке
+}
+
+// healthTracker отслеживает качество разбора графиков от обхода к обходу и поднимает тревогу,
+// когда поломка скрапера выглядит как "свободных кортов нет"
+// Обход - все страницы, нужные подпискам, проверенные хотя бы раз. Тики опроса проверяют разные
+// подмножества страниц (ближние даты чаще, дальние реже), поэтому сравнивать тики между собой нельзя:
+// результаты тиков копятся, пока не покроют все страницы
+type healthTracker struct {
+	mu         sync.Mutex
+	courts     map[string]*courtHealth       // "sport:courtID"
+	lastAlert  map[string]time.Time          // вид общего оповещения -> время последней отправки
+	emptyRatio float64                       // доля пустых кортов в предыдущем обходе (-1 если обходов еще не было)
+	pending    map[scheduleKey]scheduleEntry // последние результаты страниц текущего обхода
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		courts:     make(map[string]*courtHealth),
+		lastAlert:  make(map[string]time.Time),
+		emptyRatio: -1,
+		pending:    make(map[scheduleKey]scheduleEntry),
+	}
+}
+
+// collect добавляет результаты тика к текущему обходу. Когда проверены все страницы wanted,
+// возвращает результаты обхода (только по wanted) и начинает новый
+func (h *healthTracker) collect(results map[scheduleKey]scheduleEntry, wanted []scheduleKey) (map[scheduleKey]scheduleEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, entry := range results {
+		h.pending[key] = entry
+	}
+	rotation := make(map[scheduleKey]scheduleEntry, len(wanted))
+	for _, key := range wanted {
+		entry, ok := h.pending[key]
+		if !ok {
+			return nil, false
+		}
+		rotation[key] = entry
+	}
+	h.pending = make(map[scheduleKey]scheduleEntry)
+	return rotation, true
+}
+
+// cycleResult - итог обхода для одного клуба
+type cycleResult struct {
+	pages    int
+	slots    int
+	layout   error // первая ErrLayoutChanged
+	login    bool  // хотя бы одна страница требовала входа
+	parsedOK bool  // хотя бы одна страница разобрана без ошибок
+}
+
+// observe учитывает результаты обхода расписания и возвращает тексты оповещений для администратора
+func (h *healthTracker) observe(results map[scheduleKey]scheduleEntry) []string {
+	byCourt := make(map[string]*cycleResult)
+	for key, entry := range results {
+		id := key.Sport + ":" + key.CourtID
+		r := byCourt[id]
+		if r == nil {
+			r = &cycleResult{}
+			byCourt[id] = r
+		}
+		r.pages++
+		switch {
+		case entry.err == nil:
+			r.parsedOK = true
+			r.slots += len(entry.slots)
+		case errors.Is(entry.err, parser.ErrLayoutChanged):
+			if r.layout == nil {
+				r.layout = entry.err
+			}
+		case errors.Is(entry.err, parser.ErrLoginRequired):
+			r.login = true
+		}
+	}
+	if len(byCourt) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var alerts []string
+	now := time.Now()
+	empty, login := 0, 0
+	for _, id := range sortedKeys(byCourt) {
+		r := byCourt[id]
+		state := h.courts[id]
+		if state == nil {
+			state = &courtHealth{}
+			h.courts[id] = state
+		}
+		state.LastSlots = r.slots
+
+		if r.slots == 0 {
+			empty++
+		}
+		if r.login {
+			login++
+		}
+
+		switch {
+		case r.layout != nil && !r.parsedOK:
+			state.Failures++
+			state.LastErr = r.layout
+			if state.Failures >= layoutAlertCycles && !state.Alerted {
+				state.Alerted = true
+				alerts = append(alerts, fmt.Sprintf("🧩 Не удается разобрать график %s уже %d обходов подряд — похоже, kluby.org изменил разметку.\n\n%v", id, state.Failures, r.layout))
+			}
+		case r.parsedOK:
+			if state.Alerted {
+				alerts = append(alerts, fmt.Sprintf("✅ График %s снова разбирается", id))
+			}
+			state.Failures = 0
+			state.LastErr = nil
+			state.Alerted = false
+			state.LastOK = now
+		}
+	}
+
+	total := len(byCourt)
+	ratio := float64(empty) / float64(total)
+	log.Printf("🩺 Parse health: %d/%d courts without slots, %d require login", empty, total, login)
+
+	// Почти все корты внезапно пустые - скорее всего сломался разбор, а не закончились корты
+	if total >= emptyAlertMinCourts && ratio >= emptyAlertRatio && h.emptyRatio >= 0 && h.emptyRatio < emptyHealthyRatio {
+		if h.allowAlert("empty", now) {
+			alerts = append(alerts, fmt.Sprintf("📉 %d из %d кортов внезапно не вернули ни одного свободного слота (в прошлом обходе пустых было %.0f%%). Проверь разбор kluby.org.", empty, total, h.emptyRatio*100))
+		}
+	}
+	if login*2 > total && h.allowAlert("login", now) {
+		alerts = append(alerts, fmt.Sprintf("🔐 kluby.org требует вход для %d из %d кортов — графики недоступны.", login, total))
+	}
+	h.emptyRatio = ratio
+
+	return alerts
+}
+
+// allowAlert ограничивает частоту общих оповещений одного вида (вызывается под h.mu)
+func (h *healthTracker) allowAlert(kind string, now time.Time) bool {
+	if last, ok := h.lastAlert[kind]; ok && now.Sub(last) < healthAlertInterval {
+		return false
+	}
+	h.lastAlert[kind] = now
+	return true
+}
+
+// summary возвращает клубы, которые сейчас не разбираются (для логов и админских команд)
+func (h *healthTracker) summary() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	broken := make([]string, 0)
+	for id, state := range h.courts {
+		if state.Failures > 0 {
+			broken = append(broken, fmt.Sprintf("%s (%d)", id, state.Failures))
+		}
+	}
+	sort.Strings(broken)
+	if len(broken) == 0 {
+		return "все клубы разбираются"
+	}
+	return "не разбираются: " + strings.Join(broken, ", ")
+}
+
+func sortedKeys(m map[string]*cycleResult) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
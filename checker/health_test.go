@@ -0,0 +1,27 @@
+package checker
+
+import "testing"
+
+func TestHealthCollectWaitsForFullRotation(t *testing.T) {
+	h := newHealthTracker()
+	near := scheduleKey{Sport: "tenis", CourtID: "klub", Date: "2025-11-05"}
+	far := scheduleKey{Sport: "tenis", CourtID: "klub", Date: "2025-11-15"}
+	wanted := []scheduleKey{near, far}
+
+	// Ближняя дата проверяется чаще: два тика без дальней - обход еще не закончен
+	for i := 0; i < 2; i++ {
+		if _, ok := h.collect(map[scheduleKey]scheduleEntry{near: {}}, wanted); ok {
+			t.Fatalf("tick %d completed a rotation without the far page", i)
+		}
+	}
+
+	rotation, ok := h.collect(map[scheduleKey]scheduleEntry{far: {}}, wanted)
+	if !ok || len(rotation) != 2 {
+		t.Fatalf("rotation = %v, %v; want both pages", rotation, ok)
+	}
+
+	// Новый обход начинается с нуля
+	if _, ok := h.collect(map[scheduleKey]scheduleEntry{near: {}}, wanted); ok {
+		t.Error("next rotation completed after one tick")
+	}
+}
@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"court-bot/types"
+)
+
+// recordLifecycle сохраняет жизненный цикл слотов всех загруженных в цикле страниц
+// (до фильтров подписок - история нужна и для аналитики). Страницы с ошибкой пропускаются:
+// их слоты неизвестны и не должны считаться исчезнувшими
+func (c *Checker) recordLifecycle(ctx context.Context, cache *scheduleCache) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	now := time.Now()
+	cache.observedAt = now
+
+	pages, failed := 0, 0
+	for key, entry := range cache.results() {
+		if entry.err != nil {
+			continue
+		}
+		if err := c.Store.ObserveSchedulePage(key.Sport, key.CourtID, key.Date, entry.slots, now); err != nil {
+			log.Printf("⚠️ Failed to record slot lifecycle for %s on %s: %v", key.CourtID, key.Date, err)
+			failed++
+			continue
+		}
+		pages++
+	}
+	log.Printf("🗂 Slot lifecycle updated for %d pages (%d failed)", pages, failed)
+}
+
+// freshSlots оставляет слоты, ставшие доступными в текущем цикле (по жизненному циклу).
+// Используется, когда сохраненное состояние подписки потеряно: без него все слоты выглядели бы новыми
+func (c *Checker) freshSlots(slots []types.Slot, cache *scheduleCache) []types.Slot {
+	if cache.observedAt.IsZero() {
+		return slots
+	}
+
+	ids := make([]string, len(slots))
+	for i, slot := range slots {
+		ids[i] = slot.UniqueID()
+	}
+	records, err := c.Store.GetLifecycles(ids)
+	if err != nil {
+		log.Printf("⚠️ Failed to load slot lifecycle: %v", err)
+		return slots
+	}
+
+	fresh := make([]types.Slot, 0)
+	for _, slot := range slots {
+		rec, ok := records[slot.UniqueID()]
+		if !ok || !rec.AppearedAt.Before(cache.observedAt) {
+			fresh = append(fresh, slot)
+		}
+	}
+	return fresh
+}
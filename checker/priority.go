@@ -0,0 +1,275 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+)
+
+// pollTick - как часто планировщик просматривает, какие страницы графика пора проверить
+const pollTick = time.Minute
+
+// errNotDue - страница графика не входит в текущий цикл (ее время проверки еще не пришло)
+var errNotDue = errors.New("schedule page is not due")
+
+// daysAhead возвращает, через сколько дней наступит дата (0 = сегодня) по часовому поясу now
+func daysAhead(date string, now time.Time) int {
+	d, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil {
+		return 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Округляем, чтобы переход на летнее/зимнее время не сдвигал день
+	return int(math.Round(d.Sub(today).Hours() / 24))
+}
+
+// pollMember - ключ страницы графика в расписании опроса
+func (k scheduleKey) pollMember() string {
+	return k.Sport + ":" + k.CourtID + ":" + k.Date
+}
+
+// pollLoop раз в pollTick проверяет страницы графика, время которых пришло
+func (c *Checker) pollLoop(ctx context.Context) {
+	ticker := time.NewTicker(pollTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("🛑 Checker service stopped")
+			return
+		case <-ticker.C:
+			c.pollDue(ctx, time.Now())
+		}
+	}
+}
+
+// pollDue загружает только страницы графика, у которых наступило время проверки,
+// и раздает результаты подпискам. Остальные страницы сохраняют состояние прошлой проверки
+func (c *Checker) pollDue(ctx context.Context, now time.Time) {
+	subscriptions, err := c.Store.List()
+	if err != nil {
+		log.Printf("⚠️ Error fetching subscriptions: %v", err)
+		return
+	}
+
+	// Паузы и сроки проверяем каждый тик, чтобы напоминания приходили вовремя
+	c.updateLifetimes(subscriptions, now)
+	subscriptions = activeSubscriptions(subscriptions, now)
+
+	due, err := c.Store.GetPollDue()
+	if err != nil {
+		log.Printf("⚠️ Error fetching poll schedule: %v", err)
+		return
+	}
+
+	keys := c.unionKeys(subscriptions)
+	wanted := make(map[string]bool, len(keys))
+	dueKeys := make([]scheduleKey, 0)
+	for _, key := range keys {
+		member := key.pollMember()
+		wanted[member] = true
+		// Новые страницы (нет в расписании) проверяем сразу
+		if at, ok := due[member]; !ok || !at.After(now) {
+			dueKeys = append(dueKeys, key)
+		}
+	}
+
+	// Страницы, которые больше не нужны ни одной подписке, убираем из расписания
+	stale := make([]string, 0)
+	for member := range due {
+		if !wanted[member] {
+			stale = append(stale, member)
+		}
+	}
+	if err := c.Store.RemovePollDue(stale); err != nil {
+		log.Printf("⚠️ Error pruning poll schedule: %v", err)
+	}
+
+	if len(dueKeys) == 0 {
+		return
+	}
+
+	log.Printf("🔍 %d of %d schedule pages are due (%s)", len(dueKeys), len(keys), c.tierSummary(subscriptions, dueKeys, now))
+	c.runCycle(ctx, subscriptions, dueKeys, false)
+}
+
+// schedulePolled записывает время следующей проверки для загруженных в цикле страниц
+// Незагруженные (прерванный цикл) страницы остаются в расписании просроченными
+func (c *Checker) schedulePolled(subscriptions []*storage.Subscription, cache *scheduleCache) {
+	locs := c.keyLocations(subscriptions)
+	next := make(map[string]time.Time)
+	for key := range cache.results() {
+		loc := locs[key]
+		if loc == nil {
+			loc = types.CityLocation("")
+		}
+		now := time.Now().In(loc)
+		next[key.pollMember()] = now.Add(c.Schedule.interval(key.Date, now))
+	}
+	if err := c.Store.SetPollDue(next); err != nil {
+		log.Printf("⚠️ Error saving poll schedule: %v", err)
+	}
+}
+
+// tierSummary считает страницы по тирам опроса для логов ("1-2 дн.: 12, 3-7 дн.: 4")
+func (c *Checker) tierSummary(subscriptions []*storage.Subscription, keys []scheduleKey, now time.Time) string {
+	locs := c.keyLocations(subscriptions)
+	counts := make(map[string]int)
+	order := make(map[string]int) // тир -> MaxDays для сортировки
+	labels := make([]string, 0)
+	for _, key := range keys {
+		loc := locs[key]
+		if loc == nil {
+			loc = types.CityLocation("")
+		}
+		w := c.Schedule.window(now.In(loc))
+		tier := w.tier(key.Date, now.In(loc))
+		label := w.tierLabel(tier)
+		if counts[label] == 0 {
+			labels = append(labels, label)
+			order[label] = tier.MaxDays
+		}
+		counts[label]++
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return order[labels[i]] < order[labels[j]] })
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		parts = append(parts, fmt.Sprintf("%s: %d", label, counts[label]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keyLocations сопоставляет страницам графика часовой пояс города подписки
+func (c *Checker) keyLocations(subscriptions []*storage.Subscription) map[scheduleKey]*time.Location {
+	locs := make(map[scheduleKey]*time.Location)
+	for _, sub := range subscriptions {
+		if !isComplete(sub) {
+			continue
+		}
+		loc := types.CityLocation(sub.City)
+		for _, key := range c.scheduleKeys(sub) {
+			if _, ok := locs[key]; !ok {
+				locs[key] = loc
+			}
+		}
+	}
+	return locs
+}
+
+// fullCycleTimeout ограничивает полный цикл, запущенный администратором
+const fullCycleTimeout = 30 * time.Minute
+
+// cycleStatus - последний выполненный цикл (для /schedule)
+type cycleStatus struct {
+	mu    sync.Mutex
+	at    time.Time
+	pages int
+}
+
+func (s *cycleStatus) record(at time.Time, pages int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.at, s.pages = at, pages
+}
+
+func (s *cycleStatus) last() (time.Time, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.at, s.pages
+}
+
+// RunFullCycle запускает внеочередную проверку всех страниц графика в фоне
+// Возвращает false, если полный цикл уже идет
+func (c *Checker) RunFullCycle() bool {
+	if !c.fullCycle.CompareAndSwap(false, true) {
+		return false
+	}
+
+	go func() {
+		defer c.fullCycle.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), fullCycleTimeout)
+		defer cancel()
+
+		log.Println("▶️ Full cycle requested by admin")
+		c.checkAll(ctx, false)
+	}()
+	return true
+}
+
+// PollSummary описывает для пользователей, как далеко вперед и как часто бот смотрит графики
+func (c *Checker) PollSummary() string {
+	return fmt.Sprintf("видит слоты не дальше чем на %d дней вперед и проверяет их по расписанию: %s", HorizonDays, c.Schedule.summary())
+}
+
+// ScheduleReport описывает расписание опроса и ближайшие запланированные проверки (для /schedule)
+func (c *Checker) ScheduleReport(limit int) (string, error) {
+	due, err := c.Store.GetPollDue()
+	if err != nil {
+		return "", err
+	}
+
+	// Окна расписания заданы по времени города (как в pollDue) - сервер может жить в UTC
+	now := time.Now().In(types.CityLocation(""))
+	var b strings.Builder
+	b.WriteString("🗓 Расписание проверок\n\n")
+	current := c.Schedule.window(now)
+	for i := range c.Schedule.Windows {
+		w := &c.Schedule.Windows[i]
+		marker := "•"
+		if w == current {
+			marker = "▶️"
+		}
+		fmt.Fprintf(&b, "%s %s\n", marker, w)
+	}
+
+	// Группируем страницы по минуте следующей проверки: планировщик просыпается раз в pollTick
+	overdue := 0
+	byMinute := make(map[time.Time]int)
+	for _, at := range due {
+		if !at.After(now) {
+			overdue++
+			continue
+		}
+		byMinute[at.In(now.Location()).Truncate(pollTick)]++
+	}
+	runs := make([]time.Time, 0, len(byMinute))
+	for at := range byMinute {
+		runs = append(runs, at)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	fmt.Fprintf(&b, "\n📄 Страниц в расписании: %d", len(due))
+	if overdue > 0 {
+		fmt.Fprintf(&b, ", ждут проверки: %d", overdue)
+	}
+	b.WriteString("\n")
+
+	if len(runs) > 0 {
+		b.WriteString("\n⏭ Ближайшие проверки:\n")
+		for _, at := range runs {
+			fmt.Fprintf(&b, "• %s — %d стр.\n", at.Format("15:04"), byMinute[at])
+		}
+	}
+
+	if at, pages := c.status.last(); !at.IsZero() {
+		fmt.Fprintf(&b, "\n🕑 Последний цикл: %s, %d стр.", at.In(now.Location()).Format("02.01 15:04"), pages)
+	}
+	if c.fullCycle.Load() {
+		b.WriteString("\n▶️ Полный цикл выполняется")
+	}
+	return b.String(), nil
+}
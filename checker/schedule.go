@@ -0,0 +1,211 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPollSchedule - расписание опроса по умолчанию:
+// днем ближние даты каждые 5 минут, ночью (с 1:00 до 8:00) все реже
+const DefaultPollSchedule = "08:00-01:00 d1=5m d6=30m d13=2h; 01:00-08:00 d1=1h d6=4h d13=4h"
+
+// pollTier - как часто проверять даты, до которых осталось не больше MaxDays дней
+type pollTier struct {
+	MaxDays int // последний день тира (0 = сегодня)
+	Every   time.Duration
+}
+
+// PollWindow - интервалы опроса в окне времени суток [From, To) по местному времени
+// Окно может переходить через полночь ("22:00-06:00")
+type PollWindow struct {
+	From, To int // минуты от начала суток
+	Tiers    []pollTier
+}
+
+// PollSchedule - расписание опроса страниц графика: окна времени суток с интервалами по удаленности даты
+// Действует первое окно, в которое попадает текущее время; если ни одно не подходит - первое окно
+type PollSchedule struct {
+	Windows []PollWindow
+}
+
+// ParsePollSchedule разбирает расписание вида
+//
+//	08:00-01:00 d1=5m d6=30m d13=2h; 01:00-08:00 d1=1h d6=4h d13=4h
+//
+// Окна разделяются ";" или переводом строки, dN=интервал - даты не дальше N дней от сегодня
+// Строки, начинающиеся с "#", пропускаются
+func ParsePollSchedule(text string) (*PollSchedule, error) {
+	s := &PollSchedule{}
+	lines := strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '\n' })
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		w, err := parsePollWindow(line)
+		if err != nil {
+			return nil, fmt.Errorf("poll schedule %q: %w", line, err)
+		}
+		s.Windows = append(s.Windows, w)
+	}
+	if len(s.Windows) == 0 {
+		return nil, fmt.Errorf("poll schedule is empty")
+	}
+	return s, nil
+}
+
+// parsePollWindow разбирает одно окно: "08:00-01:00 d1=5m d6=30m d13=2h"
+func parsePollWindow(line string) (PollWindow, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return PollWindow{}, fmt.Errorf("expected time window and at least one dN=interval")
+	}
+
+	var w PollWindow
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return w, fmt.Errorf("bad time window %q", fields[0])
+	}
+	var okFrom, okTo bool
+	w.From, okFrom = minutesOfDay(from)
+	w.To, okTo = minutesOfDay(to)
+	if !okFrom || !okTo || w.From == w.To || w.From >= 24*60 || w.To >= 24*60 {
+		return w, fmt.Errorf("bad time window %q", fields[0])
+	}
+
+	for _, f := range fields[1:] {
+		days, every, ok := strings.Cut(f, "=")
+		if !ok || !strings.HasPrefix(days, "d") {
+			return w, fmt.Errorf("bad tier %q, expected dN=interval", f)
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(days, "d"))
+		if err != nil || n < 0 {
+			return w, fmt.Errorf("bad tier %q: days must be a non-negative number", f)
+		}
+		d, err := time.ParseDuration(every)
+		if err != nil || d < pollTick {
+			return w, fmt.Errorf("bad tier %q: interval must be at least %s", f, pollTick)
+		}
+		w.Tiers = append(w.Tiers, pollTier{MaxDays: n, Every: d})
+	}
+	sort.Slice(w.Tiers, func(i, j int) bool { return w.Tiers[i].MaxDays < w.Tiers[j].MaxDays })
+	return w, nil
+}
+
+// LoadPollSchedule читает расписание из CHECK_SCHEDULE (строка) или CHECK_SCHEDULE_FILE (путь к файлу)
+// Если ничего не задано - DefaultPollSchedule
+func LoadPollSchedule() (*PollSchedule, error) {
+	if text := os.Getenv("CHECK_SCHEDULE"); text != "" {
+		return ParsePollSchedule(text)
+	}
+	if path := os.Getenv("CHECK_SCHEDULE_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePollSchedule(string(data))
+	}
+	return ParsePollSchedule(DefaultPollSchedule)
+}
+
+// contains проверяет, попадает ли время в окно (с учетом перехода через полночь)
+func (w *PollWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.From < w.To {
+		return m >= w.From && m < w.To
+	}
+	return m >= w.From || m < w.To
+}
+
+// tier возвращает тир для даты (даты дальше последнего тира проверяются с его интервалом)
+func (w *PollWindow) tier(date string, now time.Time) pollTier {
+	days := daysAhead(date, now)
+	for _, tier := range w.Tiers {
+		if days <= tier.MaxDays {
+			return tier
+		}
+	}
+	return w.Tiers[len(w.Tiers)-1]
+}
+
+// window возвращает окно, действующее в момент now
+func (s *PollSchedule) window(now time.Time) *PollWindow {
+	for i := range s.Windows {
+		if s.Windows[i].contains(now) {
+			return &s.Windows[i]
+		}
+	}
+	return &s.Windows[0]
+}
+
+// interval возвращает, через сколько снова проверять страницу графика с этой датой
+func (s *PollSchedule) interval(date string, now time.Time) time.Duration {
+	return s.window(now).tier(date, now).Every
+}
+
+// tierLabel - название тира для логов и /schedule ("1-2 дн.", "3-7 дн.")
+func (w *PollWindow) tierLabel(tier pollTier) string {
+	from := 0
+	for _, t := range w.Tiers {
+		if t.MaxDays == tier.MaxDays {
+			break
+		}
+		from = t.MaxDays + 1
+	}
+	if from == tier.MaxDays {
+		return fmt.Sprintf("%d дн.", from+1)
+	}
+	return fmt.Sprintf("%d-%d дн.", from+1, tier.MaxDays+1)
+}
+
+// String описывает окно: "08:00-01:00: 1-2 дн. — 5m, 3-7 дн. — 30m, 8-14 дн. — 2h"
+func (w *PollWindow) String() string {
+	parts := make([]string, 0, len(w.Tiers))
+	for _, tier := range w.Tiers {
+		parts = append(parts, fmt.Sprintf("%s — %s", w.tierLabel(tier), formatEvery(tier.Every)))
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d: %s", w.From/60, w.From%60, w.To/60, w.To%60, strings.Join(parts, ", "))
+}
+
+// summary описывает расписание для пользователей: "ближайшие даты — раз в 5 мин, дальние — до раза в 4 ч"
+// Берется самый частый интервал ближних дат и самый редкий - дальних по всем окнам суток
+func (s *PollSchedule) summary() string {
+	nearest, farthest := s.Windows[0].Tiers[0].Every, time.Duration(0)
+	for _, w := range s.Windows {
+		nearest = min(nearest, w.Tiers[0].Every)
+		farthest = max(farthest, w.Tiers[len(w.Tiers)-1].Every)
+	}
+	if nearest == farthest {
+		return "все даты — раз в " + formatEveryRu(nearest)
+	}
+	return fmt.Sprintf("ближайшие даты — раз в %s, дальние — до раза в %s", formatEveryRu(nearest), formatEveryRu(farthest))
+}
+
+// formatEveryRu печатает интервал для пользователей: "5 мин", "2 ч", "1 ч 30 мин"
+func formatEveryRu(d time.Duration) string {
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%d мин", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%d ч", hours)
+	default:
+		return fmt.Sprintf("%d ч %d мин", hours, minutes)
+	}
+}
+
+// formatEvery печатает интервал без лишних нулей ("2h", "30m", "1h30m")
+func formatEvery(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
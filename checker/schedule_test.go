@@ -0,0 +1,69 @@
+package checker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollScheduleIntervals(t *testing.T) {
+	s, err := ParsePollSchedule(DefaultPollSchedule)
+	if err != nil {
+		t.Fatalf("default schedule: %v", err)
+	}
+
+	loc := time.FixedZone("CET", 3600)
+	day := time.Date(2025, 11, 5, 18, 30, 0, 0, loc)
+	night := time.Date(2025, 11, 5, 3, 0, 0, 0, loc)
+
+	cases := []struct {
+		now  time.Time
+		date string
+		want time.Duration
+	}{
+		{day, "2025-11-05", 5 * time.Minute},
+		{day, "2025-11-06", 5 * time.Minute},
+		{day, "2025-11-07", 30 * time.Minute},
+		{day, "2025-11-18", 2 * time.Hour},
+		{night, "2025-11-05", time.Hour},
+		{night, "2025-11-12", 4 * time.Hour},
+		// Окно 08:00-01:00 переходит через полночь
+		{time.Date(2025, 11, 5, 0, 30, 0, 0, loc), "2025-11-05", 5 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := s.interval(tc.date, tc.now); got != tc.want {
+			t.Errorf("interval(%s at %s) = %s, want %s", tc.date, tc.now.Format("15:04"), got, tc.want)
+		}
+	}
+
+	if got := s.Windows[0].String(); got != "08:00-01:00: 1-2 дн. — 5m, 3-7 дн. — 30m, 8-14 дн. — 2h" {
+		t.Errorf("window string = %q", got)
+	}
+	if got, want := s.summary(), "ближайшие даты — раз в 5 мин, дальние — до раза в 4 ч"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	flat, err := ParsePollSchedule("08:00-20:00 d13=1h30m; 20:00-08:00 d13=1h30m")
+	if err != nil {
+		t.Fatalf("flat schedule: %v", err)
+	}
+	if got, want := flat.summary(), "все даты — раз в 1 ч 30 мин"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+}
+
+func TestParsePollScheduleErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"# только комментарий",
+		"08:00 d1=5m",
+		"08:00-08:00 d1=5m",
+		"25:00-08:00 d1=5m",
+		"08:00-01:00",
+		"08:00-01:00 x1=5m",
+		"08:00-01:00 d1=10s",
+	} {
+		if _, err := ParsePollSchedule(text); err == nil {
+			t.Errorf("ParsePollSchedule(%q) succeeded, want error", text)
+		}
+	}
+}
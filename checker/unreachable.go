@@ -0,0 +1,85 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	"court-bot/parser"
+	"court-bot/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// unreachableNotices помнит, каким чатам уже сообщили о недоступности клуба (клуб -> чаты),
+// чтобы за один сбой подписчик получил одно сообщение. Сбрасывается, когда клуб снова отвечает
+type unreachableNotices struct {
+	mu   sync.Mutex
+	sent map[string]map[int64]bool
+}
+
+func newUnreachableNotices() *unreachableNotices {
+	return &unreachableNotices{sent: make(map[string]map[int64]bool)}
+}
+
+// notifyUnreachable сообщает подписчикам о клубах, для которых сработал предохранитель в этом цикле
+func (c *Checker) notifyUnreachable(ctx context.Context, subscriptions []*storage.Subscription, cache *scheduleCache) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Клуб недоступен, если предохранитель открыт и ни одна страница цикла не загрузилась
+	open := make(map[string]bool)
+	ok := make(map[string]bool)
+	for key, entry := range cache.results() {
+		switch {
+		case entry.err == nil:
+			ok[key.CourtID] = true
+		case errors.Is(entry.err, parser.ErrCircuitOpen):
+			open[key.CourtID] = true
+		}
+	}
+
+	n := c.unreachable
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for club := range ok {
+		if len(n.sent[club]) > 0 {
+			log.Printf("🔌 Club %s is reachable again", club)
+		}
+		delete(n.sent, club)
+	}
+
+	for club := range open {
+		if ok[club] {
+			continue
+		}
+		if n.sent[club] == nil {
+			n.sent[club] = make(map[int64]bool)
+		}
+		name := c.clubName(club)
+		for _, sub := range subscriptions {
+			if !isComplete(sub) || n.sent[club][sub.ChatID] || !slices.Contains(sub.Courts, club) {
+				continue
+			}
+			text := fmt.Sprintf("⚠️ Клуб %s сейчас не отвечает — временно не могу проверить его корты.\n\nПроверка возобновится автоматически, когда клуб снова станет доступен.", name)
+			if _, err := c.Bot.Send(tgbotapi.NewMessage(sub.ChatID, text)); err != nil {
+				log.Printf("⚠️ Failed to send unreachable notice to %d: %v", sub.ChatID, err)
+				continue
+			}
+			n.sent[club][sub.ChatID] = true
+		}
+	}
+}
+
+// clubName возвращает название клуба из каталога (или ID, если клуба там нет)
+func (c *Checker) clubName(id string) string {
+	if court, err := c.Store.GetCatalogCourt(id); err == nil && court != nil && court.Name != "" {
+		return court.Name
+	}
+	return id
+}
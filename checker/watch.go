@@ -0,0 +1,160 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// watchInterval - как часто проверять корты со слежением за отменами (чаще основного цикла)
+const watchInterval = 3 * time.Minute
+
+// watchLoop периодически проверяет слежения за отменами
+func (c *Checker) watchLoop(ctx context.Context) {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.checkWatches(ctx)
+		}
+	}
+}
+
+// checkWatches загружает графики всех наблюдаемых (корт, дата) и уведомляет, если ячейка освободилась
+func (c *Checker) checkWatches(ctx context.Context) {
+	chats, err := c.Store.ListWatchChats()
+	if err != nil {
+		log.Printf("⚠️ Error fetching watches: %v", err)
+		return
+	}
+	if len(chats) == 0 {
+		return
+	}
+
+	// Сначала собираем актуальные слежения и уникальные страницы графика
+	active := make(map[int64][]storage.Watch)
+	keys := make([]scheduleKey, 0)
+	seen := make(map[scheduleKey]bool)
+	for _, chatID := range chats {
+		watches, err := c.Store.GetWatches(chatID)
+		if err != nil {
+			log.Printf("⚠️ Error fetching watches for %d: %v", chatID, err)
+			continue
+		}
+
+		kept := make([]storage.Watch, 0, len(watches))
+		expired := make([]string, 0)
+		for _, w := range watches {
+			if watchExpired(w) {
+				c.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⌛ %s не освободился — слежение снято.", formatWatch(w))))
+				expired = append(expired, w.ID)
+				continue
+			}
+			kept = append(kept, w)
+
+			key := scheduleKey{Sport: types.SportOrDefault(w.Sport), CourtID: w.ClubID, Date: w.Date}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		c.removeWatches(chatID, expired)
+		if len(kept) > 0 {
+			active[chatID] = kept
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	log.Printf("👀 Checking %d watched schedule pages", len(keys))
+	cache := newScheduleCache(c.Source)
+	cache.prefetch(ctx, keys, c.Workers)
+
+	for chatID, watches := range active {
+		fired := make([]string, 0)
+		for _, w := range watches {
+			slots, err := cache.get(ctx, scheduleKey{Sport: types.SportOrDefault(w.Sport), CourtID: w.ClubID, Date: w.Date})
+			if err != nil {
+				continue
+			}
+
+			slot, ok := findWatchedSlot(slots, w)
+			if !ok {
+				continue
+			}
+
+			text := fmt.Sprintf("🎉 Освободилось! %s\n\nБронируй скорее: %s", formatWatch(w), slot.URL)
+			if _, err := c.Bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+				log.Printf("⚠️ Failed to send watch alert to %d: %v", chatID, err)
+				continue
+			}
+			log.Printf("🎉 Watch %s fired for chatID: %d", w.ID, chatID)
+			fired = append(fired, w.ID)
+		}
+		c.removeWatches(chatID, fired)
+	}
+}
+
+// removeWatches снимает сработавшие и просроченные слежения по ID
+// Список чата не перезаписывается целиком: слежения, добавленные или удаленные во время проверки, сохраняются как есть
+func (c *Checker) removeWatches(chatID int64, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	if _, err := c.Store.RemoveWatches(chatID, ids); err != nil {
+		log.Printf("⚠️ Failed to remove watches for %d: %v", chatID, err)
+	}
+}
+
+// findWatchedSlot ищет свободный слот того же корта, покрывающий время слежения
+func findWatchedSlot(slots []types.Slot, w storage.Watch) (types.Slot, bool) {
+	want, ok := minutesOfDay(w.Time)
+	if !ok {
+		return types.Slot{}, false
+	}
+	for _, slot := range slots {
+		if slot.CourtType != w.Court {
+			continue
+		}
+		start, ok := minutesOfDay(slot.Time)
+		if !ok {
+			continue
+		}
+		if start <= want && want < start+max(slot.Duration, 1) {
+			return slot, true
+		}
+	}
+	return types.Slot{}, false
+}
+
+// watchExpired - время слежения уже наступило (по местному времени города)
+func watchExpired(w storage.Watch) bool {
+	start, err := time.ParseInLocation("2006-01-02 15:04", w.Date+" "+w.Time, types.CityLocation(w.City))
+	if err != nil {
+		return true
+	}
+	return time.Now().After(start)
+}
+
+func formatWatch(w storage.Watch) string {
+	return fmt.Sprintf("%s, %s — %s %s", w.ClubName, w.Court, w.Date, w.Time)
+}
+
+func minutesOfDay(t string) (int, bool) {
+	var h, m int
+	if _, err := fmt.Sscanf(t, "%d:%d", &h, &m); err != nil {
+		return 0, false
+	}
+	return h*60 + m, true
+}
@@ -0,0 +1,90 @@
+// record-fixtures сохраняет реальные страницы kluby.org в parser/testdata/recorded
+// для golden-тестов парсера. Email аккаунта в страницах заменяется на user@example.com;
+// остальные личные данные (имя, телефон в шапке) перед коммитом нужно проверить вручную.
+//
+// Пример:
+//
+//	KLUBY_EMAIL=... KLUBY_PASSWORD=... go run ./cmd/record-fixtures \
+//		-city warszawa -sport tenis -district Mokotów -club umacieja -date 2025-11-05
+//	go test ./parser -update
+//
+// Имена файлов кодируют параметры разбора (разделитель "--"), тест находит их сам:
+//
+//	districts--<city>--<sport>.html
+//	clubs--<city>--<sport>--<district>.html
+//	schedule--<sport>--<club>--<date>.html
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"court-bot/parser"
+	"court-bot/types"
+)
+
+func main() {
+	city := flag.String("city", types.CityWarsaw, "код города")
+	sport := flag.String("sport", types.SportTennis, "код вида спорта")
+	district := flag.String("district", "", "район для списка клубов (пусто - не записывать)")
+	clubs := flag.String("club", "", "ID клубов через запятую для графиков (пусто - не записывать)")
+	date := flag.String("date", time.Now().Format("2006-01-02"), "дата графика")
+	out := flag.String("out", filepath.Join("parser", "testdata", "recorded"), "каталог для фикстур")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("❌ Failed to create %s: %v", *out, err)
+	}
+
+	source := parser.NewKluby(nil, parser.Config{
+		RPS:      1,
+		Burst:    1,
+		Email:    os.Getenv("KLUBY_EMAIL"),
+		Password: os.Getenv("KLUBY_PASSWORD"),
+	})
+
+	record := func(name, pageURL string) {
+		body, err := source.FetchPage(ctx, pageURL)
+		if err != nil {
+			log.Fatalf("❌ Failed to fetch %s: %v", pageURL, err)
+		}
+		if email := os.Getenv("KLUBY_EMAIL"); email != "" {
+			body = bytes.ReplaceAll(body, []byte(email), []byte("user@example.com"))
+		}
+		path := filepath.Join(*out, name+".html")
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			log.Fatalf("❌ Failed to write %s: %v", path, err)
+		}
+		log.Printf("💾 %s → %s (%d bytes)", pageURL, path, len(body))
+	}
+
+	record(fixtureName("districts", *city, *sport), parser.DistrictsURL(*city, *sport))
+
+	if *district != "" {
+		record(fixtureName("clubs", *city, *sport, *district), parser.ClubListURL(*city, *sport, *district))
+	}
+
+	for _, club := range strings.Split(*clubs, ",") {
+		club = strings.TrimSpace(club)
+		if club == "" {
+			continue
+		}
+		record(fixtureName("schedule", *sport, club, *date), parser.ScheduleURL(*sport, club, *date))
+	}
+}
+
+// fixtureName собирает имя файла фикстуры из вида страницы и параметров разбора
+func fixtureName(kind string, parts ...string) string {
+	return kind + "--" + strings.Join(parts, "--")
+}
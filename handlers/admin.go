@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// scheduleRunsShown - сколько ближайших проверок показывать в /schedule
+const scheduleRunsShown = 8
+
+// isAdmin проверяет, что команду прислал администратор (ADMIN_CHAT_ID)
+func (h *Handler) isAdmin(chatID int64) bool {
+	return h.AdminChatID != 0 && chatID == h.AdminChatID
+}
+
+// HandleSchedule показывает администратору расписание опроса и ближайшие проверки
+func (h *Handler) HandleSchedule(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	if !h.isAdmin(chatID) {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "Неизвестная команда. Попробуй /start"))
+		return
+	}
+	if h.Checker == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Сервис проверки временно недоступен."))
+		return
+	}
+
+	text, err := h.Checker.ScheduleReport(scheduleRunsShown)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке расписания: "+err.Error()))
+		return
+	}
+
+	reply := tgbotapi.NewMessage(chatID, text)
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("▶️ Запустить полный цикл", "schedule_run"),
+		),
+	)
+	h.Bot.Send(reply)
+}
+
+// HandleScheduleRun запускает внеочередной полный цикл проверки по кнопке из /schedule
+func (h *Handler) HandleScheduleRun(cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+	if !h.isAdmin(chatID) || h.Checker == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Недоступно"))
+		return
+	}
+
+	if !h.Checker.RunFullCycle() {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Полный цикл уже выполняется"))
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Запущено"))
+	h.Bot.Send(tgbotapi.NewMessage(chatID, "▶️ Полный цикл проверки запущен. Новые слоты придут подписчикам как обычно."))
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	calendarMonthsAhead  = 3  // сколько месяцев (включая текущий) можно листать в календаре
+	maxSubscriptionDates = 31 // конкретных дат в одной подписке
+	checkDaysAhead       = 14 // на сколько дней вперед можно проверить дату в /check (горизонт проверки)
+)
+
+var monthNames = []string{
+	"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь",
+}
+
+// calendarDraft - состояние календаря выбора дат (шаг 6 в режиме конкретных дат)
+type calendarDraft struct {
+	Month      time.Time // первое число показанного месяца
+	Range      bool      // следующие нажатия выбирают диапазон
+	RangeStart string    // начало диапазона, ждем второе нажатие
+}
+
+// calendarDrafts хранит состояние календаря по чатам (как userSelections)
+var calendarDrafts = make(map[int64]*calendarDraft)
+
+// HandleDatesPick открывает календарь вместо выбора дней недели
+func (h *Handler) HandleDatesPick(cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+
+	// Открываем месяц первой выбранной даты, иначе текущий
+	month := startOfMonth(time.Now().In(types.CityLocation(sub.City)))
+	if len(sub.Dates) > 0 {
+		if d, err := time.ParseInLocation("2006-01-02", sub.Dates[0], month.Location()); err == nil && !d.Before(month) {
+			month = startOfMonth(d)
+		}
+	}
+	calendarDrafts[chatID] = &calendarDraft{Month: month}
+
+	msg := tgbotapi.NewMessage(chatID, "🗓 Шаг 6/9: Выбери даты\n\nНажми на даты, в которые искать свободные корты. Для периода нажми «Выбрать диапазон», затем первый и последний день.")
+	msg.ReplyMarkup = h.buildCalendarKeyboard(sub, calendarDrafts[chatID])
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildCalendarKeyboard(sub *storage.Subscription, draft *calendarDraft) tgbotapi.InlineKeyboardMarkup {
+	now := time.Now().In(draft.Month.Location())
+	today := now.Format("2006-01-02")
+	first := startOfMonth(now)
+
+	selected := make(map[string]bool)
+	for _, d := range sub.Dates {
+		selected[d] = true
+	}
+
+	noop := func(text string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(text, "cal_noop")
+	}
+
+	// Заголовок с листанием месяцев
+	prev, next := noop(" "), noop(" ")
+	if draft.Month.After(first) {
+		prev = tgbotapi.NewInlineKeyboardButtonData("◀️", "cal_nav:"+draft.Month.AddDate(0, -1, 0).Format("2006-01"))
+	}
+	if draft.Month.Before(first.AddDate(0, calendarMonthsAhead-1, 0)) {
+		next = tgbotapi.NewInlineKeyboardButtonData("▶️", "cal_nav:"+draft.Month.AddDate(0, 1, 0).Format("2006-01"))
+	}
+	title := fmt.Sprintf("%s %d", monthNames[draft.Month.Month()-1], draft.Month.Year())
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(prev, noop(title), next)}
+
+	header := make([]tgbotapi.InlineKeyboardButton, 0, 7)
+	for _, day := range weekDays {
+		header = append(header, noop(dayNames[day.Code]))
+	}
+	rows = append(rows, header)
+
+	// Сетка месяца, неделя начинается с понедельника
+	row := make([]tgbotapi.InlineKeyboardButton, 0, 7)
+	for i := 0; i < (int(draft.Month.Weekday())+6)%7; i++ {
+		row = append(row, noop(" "))
+	}
+	for d := draft.Month; d.Month() == draft.Month.Month(); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		label := fmt.Sprintf("%d", d.Day())
+		switch {
+		case date < today:
+			row = append(row, noop("·"))
+		case date == draft.RangeStart:
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData("»"+label, "cal_day:"+date))
+		case selected[date]:
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData("["+label+"]", "cal_day:"+date))
+		default:
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "cal_day:"+date))
+		}
+		if len(row) == 7 {
+			rows = append(rows, row)
+			row = make([]tgbotapi.InlineKeyboardButton, 0, 7)
+		}
+	}
+	if len(row) > 0 {
+		for len(row) < 7 {
+			row = append(row, noop(" "))
+		}
+		rows = append(rows, row)
+	}
+
+	rangeLabel := "↔️ Выбрать диапазон"
+	if draft.Range && draft.RangeStart != "" {
+		rangeLabel = "↔️ Диапазон с " + formatShortDate(draft.RangeStart) + " по ..."
+	} else if draft.Range {
+		rangeLabel = "↔️ Диапазон: нажми первый день"
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(rangeLabel, "cal_range"),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Сбросить", "cal_clear"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📅 Дни недели", "cal_weekdays"),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Готово", "cal_done"),
+		),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// HandleCalendar обрабатывает кнопки календаря: cal_nav:<месяц>, cal_day:<дата>, cal_range, cal_clear
+func (h *Handler) HandleCalendar(cq *tgbotapi.CallbackQuery, action, arg string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	draft := calendarDrafts[chatID]
+	if err != nil || sub == nil || draft == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Календарь устарел, начни выбор заново"))
+		return
+	}
+
+	answer := ""
+	if action == "day" && !calendarDateAllowed(arg, draft.Month.Location()) {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Эту дату выбрать нельзя"))
+		return
+	}
+	if action == "day" && h.checkMode[chatID] {
+		if _, err := parseCheckDate(arg, time.Now().In(draft.Month.Location())); err != nil {
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, fmt.Sprintf("⚠️ Проверить можно только ближайшие %d дней", checkDaysAhead)))
+			return
+		}
+	}
+	switch action {
+	case "nav":
+		month, err := time.ParseInLocation("2006-01", arg, draft.Month.Location())
+		if err != nil {
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+			return
+		}
+		draft.Month = month
+	case "day":
+		if draft.Range && draft.RangeStart == "" {
+			draft.RangeStart = arg
+			answer = "Теперь нажми последний день"
+			break
+		}
+		if draft.Range {
+			var complete bool
+			sub.Dates, complete = addDateRange(sub.Dates, draft.RangeStart, arg, maxSubscriptionDates)
+			draft.Range, draft.RangeStart = false, ""
+			answer = "✅ Диапазон добавлен"
+			if !complete {
+				answer = fmt.Sprintf("⚠️ Добавлено только до %d дат", maxSubscriptionDates)
+			}
+		} else {
+			dates := toggleDate(sub.Dates, arg)
+			if len(dates) > maxSubscriptionDates {
+				h.Bot.Request(tgbotapi.NewCallback(cq.ID, fmt.Sprintf("⚠️ Не больше %d дат", maxSubscriptionDates)))
+				return
+			}
+			sub.Dates = dates
+		}
+	case "range":
+		draft.Range = !draft.Range
+		draft.RangeStart = ""
+	case "clear":
+		sub.Dates = nil
+		draft.Range, draft.RangeStart = false, ""
+	}
+
+	if action == "day" || action == "clear" {
+		if err := h.saveCurrent(chatID, sub); err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+			return
+		}
+	}
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, h.buildCalendarKeyboard(sub, draft))
+	h.Bot.Send(edit)
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, answer))
+}
+
+// HandleCalendarWeekdays возвращает к выбору дней недели (конкретные даты сбрасываются)
+func (h *Handler) HandleCalendarWeekdays(cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	delete(calendarDrafts, chatID)
+	sub.Dates = nil
+	if err := h.saveCurrent(chatID, sub); err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+	h.SendDaysSelection(chatID)
+}
+
+// HandleCalendarDone завершает выбор дат: подписка ищет слоты только в эти даты
+func (h *Handler) HandleCalendarDone(cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	if len(sub.Dates) == 0 {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Выбери хотя бы одну дату"))
+		return
+	}
+
+	// Даты заменяют дни недели, а с ними и окна времени по дням (остается только общее окно TimeFrom-TimeTo)
+	delete(calendarDrafts, chatID)
+	sub.Days = nil
+	sub.DayWindows = nil
+	// Новые даты - новый срок: сообщение о прошедших датах можно будет отправить снова
+	if !sub.IsExpired(time.Now()) {
+		sub.ExpiryNotified = false
+	}
+	if err := h.saveCurrent(chatID, sub); err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Даты выбраны"))
+	h.continueToTime(chatID, sub)
+}
+
+// toggleDate добавляет дату в список или убирает ее оттуда
+func toggleDate(dates []string, date string) []string {
+	result := make([]string, 0, len(dates)+1)
+	found := false
+	for _, d := range dates {
+		if d == date {
+			found = true
+		} else {
+			result = append(result, d)
+		}
+	}
+	if !found {
+		result = append(result, date)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// addDateRange добавляет даты между from и to включительно (порядок концов не важен), пока в списке меньше limit дат
+// false - диапазон добавлен не целиком
+func addDateRange(dates []string, from, to string, limit int) ([]string, bool) {
+	if to < from {
+		from, to = to, from
+	}
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil {
+		return dates, false
+	}
+
+	seen := make(map[string]bool, len(dates))
+	result := append([]string(nil), dates...)
+	for _, d := range dates {
+		seen[d] = true
+	}
+	complete := true
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		if seen[date] {
+			continue
+		}
+		if len(result) >= limit {
+			complete = false
+			break
+		}
+		result = append(result, date)
+	}
+	sort.Strings(result)
+	return result, complete
+}
+
+// calendarDateAllowed проверяет, что дату можно выбрать в календаре: не в прошлом и не дальше листаемых месяцев
+func calendarDateAllowed(date string, loc *time.Location) bool {
+	d, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return false
+	}
+	now := time.Now().In(loc)
+	return !d.Before(startOfDay(now)) && d.Before(startOfMonth(now).AddDate(0, calendarMonthsAhead, 0))
+}
+
+// formatDates печатает даты, склеивая идущие подряд: "03.11-10.11, 21.11"
+func formatDates(dates []string) string {
+	sorted := append([]string(nil), dates...)
+	sort.Strings(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && nextDate(sorted[j]) == sorted[j+1] {
+			j++
+		}
+		if i == j {
+			parts = append(parts, formatShortDate(sorted[i]))
+		} else {
+			parts = append(parts, formatShortDate(sorted[i])+"-"+formatShortDate(sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+func nextDate(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ""
+	}
+	return d.AddDate(0, 0, 1).Format("2006-01-02")
+}
+
+// formatShortDate: "2026-11-21" -> "21.11"
+func formatShortDate(date string) string {
+	d, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return d.Format("02.01")
+}
+
+// formatSubscriptionDays - дни недели или конкретные даты подписки
+func formatSubscriptionDays(sub *storage.Subscription) string {
+	if len(sub.Dates) > 0 {
+		return formatDates(sub.Dates)
+	}
+	return formatDays(sub.Days)
+}
+
+// parseCheckDate разбирает дату для /check: "2026-11-21", "21.11.2026", "21.11", "сегодня", "завтра"
+// Дата должна попадать в ближайшие checkDaysAhead дней (включая сегодня) - дальше графики не проверяются
+func parseCheckDate(args string, now time.Time) (string, error) {
+	arg := strings.ToLower(strings.TrimSpace(args))
+	today := startOfDay(now)
+	switch arg {
+	case "сегодня", "today":
+		return today.Format("2006-01-02"), nil
+	case "завтра", "tomorrow":
+		return today.AddDate(0, 0, 1).Format("2006-01-02"), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "02.01.2006", "02.01"} {
+		d, err := time.ParseInLocation(layout, arg, now.Location())
+		if err != nil {
+			continue
+		}
+		if layout == "02.01" {
+			d = time.Date(now.Year(), d.Month(), d.Day(), 0, 0, 0, 0, now.Location())
+			// Дата без года уже прошла в этом году - значит следующий год
+			if d.Before(today) {
+				d = d.AddDate(1, 0, 0)
+			}
+		}
+		if d.Before(today) {
+			return "", fmt.Errorf("check date %s is in the past", arg)
+		}
+		if !d.Before(today.AddDate(0, 0, checkDaysAhead)) {
+			return "", fmt.Errorf("check date %s is more than %d days ahead", arg, checkDaysAhead)
+		}
+		return d.Format("2006-01-02"), nil
+	}
+	return "", fmt.Errorf("unknown check date %q", args)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCheckDate(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	now := time.Date(2025, 12, 28, 15, 30, 0, 0, loc)
+
+	tests := []struct {
+		args    string
+		want    string
+		wantErr bool
+	}{
+		{args: "сегодня", want: "2025-12-28"},
+		{args: "Завтра", want: "2025-12-29"},
+		{args: "today", want: "2025-12-28"},
+		{args: "2025-12-30", want: "2025-12-30"},
+		{args: "30.12.2025", want: "2025-12-30"},
+		{args: "30.12", want: "2025-12-30"},
+		{args: "03.01", want: "2026-01-03"}, // без года и уже прошла - следующий год
+		{args: "28.12", want: "2025-12-28"},
+		{args: "10.01", want: "2026-01-10"}, // последний день горизонта
+		{args: "11.01", wantErr: true},      // дальше checkDaysAhead
+		{args: "2025-12-27", wantErr: true},
+		{args: "2026-03-01", wantErr: true},
+		{args: "послезавтра", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCheckDate(tt.args, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCheckDate(%q) = %q, want error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCheckDate(%q) = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+}
+
+func TestAddDateRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		dates    []string
+		from, to string
+		limit    int
+		want     string
+		complete bool
+	}{
+		{
+			name:     "range across months",
+			from:     "2025-11-29",
+			to:       "2025-12-02",
+			limit:    10,
+			want:     "2025-11-29 2025-11-30 2025-12-01 2025-12-02",
+			complete: true,
+		},
+		{
+			name:     "reversed ends and existing dates kept once",
+			dates:    []string{"2025-11-10", "2025-11-21"},
+			from:     "2025-11-22",
+			to:       "2025-11-20",
+			limit:    10,
+			want:     "2025-11-10 2025-11-20 2025-11-21 2025-11-22",
+			complete: true,
+		},
+		{
+			name:     "stops at the limit",
+			dates:    []string{"2025-11-01"},
+			from:     "2025-11-10",
+			to:       "2025-11-20",
+			limit:    3,
+			want:     "2025-11-01 2025-11-10 2025-11-11",
+			complete: false,
+		},
+		{
+			name:     "already selected range does not count against the limit",
+			dates:    []string{"2025-11-10", "2025-11-11"},
+			from:     "2025-11-10",
+			to:       "2025-11-11",
+			limit:    2,
+			want:     "2025-11-10 2025-11-11",
+			complete: true,
+		},
+		{
+			name:  "bad date",
+			dates: []string{"2025-11-10"},
+			from:  "2025-11-10",
+			to:    "11.11",
+			limit: 10,
+			want:  "2025-11-10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, complete := addDateRange(tt.dates, tt.from, tt.to, tt.limit)
+			if strings.Join(got, " ") != tt.want || complete != tt.complete {
+				t.Errorf("addDateRange = %v, %v; want %s, %v", got, complete, tt.want, tt.complete)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Шаг 2: Выбор города
+func (h *Handler) sendCitySelection(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "🌍 Шаг 2/9: Выбери город\n\nВ каком городе искать свободные корты?")
+	msg.ReplyMarkup = h.buildCitiesKeyboard()
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildCitiesKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, city := range types.Cities {
+		btn := tgbotapi.NewInlineKeyboardButtonData(city.Name, "city:"+city.Code)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (h *Handler) HandleCitySelect(cq *tgbotapi.CallbackQuery, city string) {
+	chatID := cq.Message.Chat.ID
+
+	if types.CityByCode(city).Code != city {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Неизвестный город"))
+		return
+	}
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	// Районы и клубы разных городов не пересекаются - сбрасываем выбор при смене города
+	if types.CityOrDefault(sub.City) != city {
+		sub.Districts = nil
+		sub.Courts = nil
+		delete(userSelections, chatID)
+	}
+	sub.City = city
+
+	err = h.saveCurrent(chatID, sub)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ "+types.CityByCode(city).Name))
+	h.sendDistrictSelection(chatID)
+}
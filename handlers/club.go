@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxClubMatches - сколько вариантов показывать кнопками при неоднозначном поиске
+const maxClubMatches = 10
+
+// surfaceNames - человекочитаемые названия покрытий
+var surfaceNames = map[string]string{
+	types.SurfaceHard:       "хард",
+	types.SurfaceClay:       "грунт",
+	types.SurfaceGrass:      "трава",
+	types.SurfaceArtificial: "искусственная трава",
+	types.SurfaceCarpet:     "ковер",
+}
+
+// HandleClub ищет клуб в каталоге по названию: /club <название>
+func (h *Handler) HandleClub(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+	query := strings.TrimSpace(msg.CommandArguments())
+	if query == "" {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "Укажи название клуба, например:\n/club Legia"))
+		return
+	}
+
+	courts, err := h.Store.ListCatalogCourts()
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке каталога клубов."))
+		return
+	}
+
+	matches := findClubs(courts, query)
+	switch {
+	case len(matches) == 0:
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "🤷 Клуб не найден.\n\nКаталог пополняется по мере настройки подписок и проверок кортов."))
+	case len(matches) == 1:
+		h.Bot.Send(tgbotapi.NewMessage(chatID, formatClubCard(&matches[0])))
+	default:
+		if len(matches) > maxClubMatches {
+			matches = matches[:maxClubMatches]
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, c := range matches {
+			btn := tgbotapi.NewInlineKeyboardButtonData(c.Name, "club:"+c.ID)
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+		}
+		reply := tgbotapi.NewMessage(chatID, "🔎 Найдено несколько клубов, выбери нужный:")
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		h.Bot.Send(reply)
+	}
+}
+
+// HandleClubSelect показывает карточку клуба, выбранного из результатов поиска
+func (h *Handler) HandleClubSelect(cq *tgbotapi.CallbackQuery, id string) {
+	chatID := cq.Message.Chat.ID
+
+	court, err := h.Store.GetCatalogCourt(id)
+	if err != nil || court == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Клуб не найден"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+	h.Bot.Send(tgbotapi.NewMessage(chatID, formatClubCard(court)))
+}
+
+// findClubs ищет клубы по подстроке названия или ID (без учета регистра), точное совпадение ID - первым
+func findClubs(courts []types.Court, query string) []types.Court {
+	q := strings.ToLower(query)
+	var matches []types.Court
+	for _, c := range courts {
+		if strings.ToLower(c.ID) == q {
+			return []types.Court{c}
+		}
+		if strings.Contains(strings.ToLower(c.Name), q) || strings.Contains(strings.ToLower(c.ID), q) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches
+}
+
+// formatClubCard форматирует карточку клуба из каталога
+func formatClubCard(c *types.Court) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🏟 %s\n\n", c.Name)
+	if c.Address != "" {
+		fmt.Fprintf(&b, "📍 Адрес: %s\n", c.Address)
+	}
+
+	location := types.CityByCode(c.City).Name
+	if c.District != "" {
+		location = c.District + ", " + location
+	}
+	fmt.Fprintf(&b, "🏙 Район: %s\n", location)
+
+	if c.DistanceKm > 0 {
+		// kluby.org не пишет, от какой точки считает расстояние - показываем как есть
+		fmt.Fprintf(&b, "🚗 Расстояние по kluby.org: %.1f км\n", c.DistanceKm)
+	}
+	if counts := formatCourtCounts(c.CourtCounts); counts != "" {
+		fmt.Fprintf(&b, "🎾 Кортов: %s\n", counts)
+	}
+	if c.Indoor || c.Outdoor {
+		fmt.Fprintf(&b, "🏠 Тип: %s\n", formatIndoorOutdoor(c))
+	}
+	if len(c.Surfaces) > 0 {
+		names := make([]string, 0, len(c.Surfaces))
+		for _, s := range c.Surfaces {
+			if name, ok := surfaceNames[s]; ok {
+				names = append(names, name)
+			} else {
+				names = append(names, s)
+			}
+		}
+		fmt.Fprintf(&b, "🟫 Покрытие: %s\n", strings.Join(names, ", "))
+	}
+	if len(c.Sports) > 0 {
+		names := make([]string, 0, len(c.Sports))
+		for _, s := range c.Sports {
+			names = append(names, types.SportName(s))
+		}
+		fmt.Fprintf(&b, "🏅 Виды спорта: %s\n", strings.Join(names, ", "))
+	}
+	if c.URL != "" {
+		fmt.Fprintf(&b, "\n🔗 %s", c.URL)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// formatCourtCounts печатает число кортов по видам спорта: "6" или "6 (🎾 Теннис), 2 (🏓 Падел)"
+func formatCourtCounts(counts map[string]int) string {
+	if len(counts) == 1 {
+		for _, n := range counts {
+			return fmt.Sprint(n)
+		}
+	}
+	parts := make([]string, 0, len(counts))
+	for _, sport := range types.Sports {
+		if n := counts[sport.Code]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d (%s)", n, sport.Name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatIndoorOutdoor(c *types.Court) string {
+	switch {
+	case c.Indoor && c.Outdoor:
+		return "крытые и открытые"
+	case c.Indoor:
+		return "крытые"
+	default:
+		return "открытые"
+	}
+}
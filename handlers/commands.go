@@ -1,103 +1,419 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"court-bot/storage"
+	"court-bot/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // CheckerInterface определяет методы для работы с checker
 type CheckerInterface interface {
-	CheckSubscriptionNow(chatID int64)
+	CheckSubscriptionNow(sub *storage.Subscription)
+	// ScheduleReport описывает расписание опроса и limit ближайших проверок
+	ScheduleReport(limit int) (string, error)
+	// RunFullCycle запускает внеочередной полный цикл (false - уже идет)
+	RunFullCycle() bool
+	// PollSummary описывает горизонт и частоту проверок для пользователей
+	PollSummary() string
+}
+
+// CourtSource определяет методы для загрузки районов, кортов и занятых слотов
+type CourtSource interface {
+	ListDistricts(ctx context.Context, city, sport string) ([]string, error)
+	ListCourts(ctx context.Context, city, sport string, districts []string) ([]types.Court, error)
+	FetchBooked(ctx context.Context, sport, courtID, date string) ([]types.Slot, error)
 }
 
 type Handler struct {
-	Bot       *tgbotapi.BotAPI
-	Store     *storage.Storage
-	Checker   CheckerInterface
-	checkMode map[int64]bool
+	Bot     *tgbotapi.BotAPI
+	Store   *storage.Storage
+	Checker CheckerInterface
+	Source  CourtSource
+	// AdminChatID - чат администратора (ADMIN_CHAT_ID), которому доступны служебные команды; 0 = никому
+	AdminChatID int64
+	checkMode   map[int64]bool
+	editField   map[int64]string // поле подписки, которое редактируется из /my_subs (после шага - сразу к итогу)
 }
 
-func New(bot *tgbotapi.BotAPI, store *storage.Storage, checker CheckerInterface) *Handler {
+func New(bot *tgbotapi.BotAPI, store *storage.Storage, checker CheckerInterface, source CourtSource) *Handler {
 	return &Handler{
 		Bot:       bot,
 		Store:     store,
 		Checker:   checker,
+		Source:    source,
 		checkMode: make(map[int64]bool),
+		editField: make(map[int64]string),
 	}
 }
 
 func (h *Handler) HandleStart(msg *tgbotapi.Message) {
-	text := "👋 Привет! Я помогу тебе отслеживать свободные теннисные корты в Варшаве.\n\n" +
+	text := "👋 Привет! Я помогу тебе отслеживать свободные корты в Варшаве, Кракове, Вроцлаве и других городах: теннис, падел, сквош, бадминтон и пиклбол.\n\n" +
 		"Доступные команды:\n" +
-		"/subscribe — настроить подписку на уведомления\n" +
-		"/my_subs — показать мои подписки\n" +
-		"/get_current — проверить прямо сейчас (по подписке)\n" +
-		"/cancel — отменить текущую подписку\n" +
-		"/check — проверить доступные корты в определенное время"
+		"/subscribe [название] — добавить подписку на уведомления\n" +
+		"/my_subs — показать, изменить или удалить мои подписки\n" +
+		"/get_current — проверить прямо сейчас (по всем подпискам)\n" +
+		"/pause [дней или дата] — приостановить уведомления, /resume — возобновить\n" +
+		"/cancel — отменить подписку\n" +
+		"/check [дата] — проверить доступные корты в определенное время\n" +
+		"/notify_gone — зачеркивать в уведомлениях слоты, которые уже заняли\n\n" +
+		"/watch [клуб] — следить за отменой брони занятого слота\n" +
+		"/watches — мои слежения за отменами\n" +
+		"/club <название> — карточка клуба: адрес, корты, покрытия\n" +
+		"/insights — когда в моих клубах обычно появляются свободные слоты"
 	h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
 }
 
+// maxSubscriptionsPerChat - сколько подписок может быть у одного чата
+const maxSubscriptionsPerChat = 10
+
+// HandleSubscribe создает новую подписку: /subscribe [название]
+// Существующие подписки не трогает - у чата их может быть несколько
 func (h *Handler) HandleSubscribe(msg *tgbotapi.Message) {
-	h.checkMode[msg.Chat.ID] = false
-	h.sendDistrictSelection(msg.Chat.ID)
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписок."))
+		return
+	}
+	if len(subs) >= maxSubscriptionsPerChat {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Можно создать не больше %d подписок. Удали лишние в /my_subs.", maxSubscriptionsPerChat)))
+		return
+	}
+
+	name := strings.TrimSpace(msg.CommandArguments())
+	if name == "" {
+		name = fmt.Sprintf("Подписка %d", len(subs)+1)
+	}
+	if len([]rune(name)) > 40 {
+		name = string([]rune(name)[:40])
+	}
+
+	sub := &storage.Subscription{ChatID: chatID, Name: name}
+	if err := h.Store.Save(sub); err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось создать подписку."))
+		return
+	}
+	if err := h.Store.SetEditing(chatID, sub.ID); err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось создать подписку."))
+		return
+	}
+
+	h.checkMode[chatID] = false
+	delete(h.editField, chatID)
+	delete(userSelections, chatID)
+	h.sendSportSelection(chatID)
 }
 
+// HandleCheckCourts - разовая проверка: /check или /check 21.11 (только одна дата вместо ближайших двух недель)
 func (h *Handler) HandleCheckCourts(msg *tgbotapi.Message) {
-	h.checkMode[msg.Chat.ID] = true
-	h.sendDistrictSelection(msg.Chat.ID)
+	chatID := msg.Chat.ID
+
+	var date string
+	if args := strings.TrimSpace(msg.CommandArguments()); args != "" {
+		d, err := parseCheckDate(args, time.Now().In(types.CityLocation("")))
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Не понял дату или она дальше %d дней. Примеры: /check 21.11, /check 2026-11-21, /check завтра", checkDaysAhead)))
+			return
+		}
+		date = d
+	}
+
+	// Начинаем проверку с чистой записи; дата из команды заменяет шаг выбора дней
+	h.Store.DeleteCheck(chatID)
+	if date != "" {
+		if err := h.Store.SaveCheck(&storage.Subscription{ChatID: chatID, Dates: []string{date}}); err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось начать проверку."))
+			return
+		}
+	}
+
+	h.checkMode[chatID] = true
+	delete(h.editField, chatID)
+	h.sendSportSelection(chatID)
 }
 
 func (h *Handler) HandleMySubscriptions(msg *tgbotapi.Message) {
-	sub, err := h.Store.Get(msg.Chat.ID)
+	h.sendSubscriptionList(msg.Chat.ID, "📬 Твои подписки:")
+}
+
+// sendSubscriptionList показывает все подписки чата - каждую отдельным сообщением со своими кнопками
+func (h *Handler) sendSubscriptionList(chatID int64, title string) {
+	subs, err := h.Store.ListChat(chatID)
 	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "⚠️ Ошибка при загрузке подписок."))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписок."))
 		return
 	}
 
-	if sub == nil {
-		h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "У тебя пока нет активных подписок.\n\nИспользуй /subscribe чтобы создать подписку."))
+	if len(subs) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "У тебя пока нет активных подписок.\n\nИспользуй /subscribe чтобы создать подписку."))
 		return
 	}
 
-	text := fmt.Sprintf("📬 Твоя подписка:\n\n"+
-		"🏙 Районы: %s\n"+
-		"🎾 Корты: %d выбрано\n"+
-		"📅 Дни: %s\n"+
-		"⏰ Время: %s - %s",
-		strings.Join(sub.Districts, ", "),
-		len(sub.Courts),
-		formatDays(sub.Days),
-		sub.TimeFrom,
-		sub.TimeTo)
+	h.Bot.Send(tgbotapi.NewMessage(chatID, title+"\n\nНовая подписка: /subscribe название"))
+	for _, sub := range subs {
+		reply := tgbotapi.NewMessage(chatID, subscriptionCard(sub))
+		reply.ReplyMarkup = subscriptionKeyboard(sub)
+		h.Bot.Send(reply)
+	}
+}
 
-	h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+// subscriptionCard - текст карточки подписки в /my_subs
+func subscriptionCard(sub *storage.Subscription) string {
+	text := "📌 " + sub.DisplayName() + "\n\n"
+	if !isSubscriptionComplete(sub) {
+		return text + "⚠️ Настройка не завершена"
+	}
+	text += formatSubscription(sub)
+	if lifetime := formatLifetime(sub, time.Now()); lifetime != "" {
+		text += "\n\n" + lifetime
+	}
+	return text
+}
+
+// subscriptionKeyboard - кнопки изменения отдельных полей, всего мастера заново и удаления подписки
+func subscriptionKeyboard(sub *storage.Subscription) tgbotapi.InlineKeyboardMarkup {
+	field := func(label, name string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(label, "sub_field:"+sub.ID+":"+name)
+	}
+	pause := tgbotapi.NewInlineKeyboardButtonData("⏸ Пауза", "sub_pause:"+sub.ID)
+	if sub.IsPaused(time.Now()) {
+		pause = tgbotapi.NewInlineKeyboardButtonData("▶️ Возобновить", "sub_pause:"+sub.ID)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			field("🏙 Районы", editDistricts),
+			field("🎾 Корты", editCourts),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			field("📅 Дни", editDays),
+			field("⏰ Время", editTime),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			field("⌛ Срок", editExpiry),
+			pause,
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔁 Настроить заново", "sub_edit:"+sub.ID),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", "sub_del:"+sub.ID),
+		),
+	)
+}
+
+// Поля подписки, которые можно изменить по отдельности
+const (
+	editDistricts = "districts"
+	editCourts    = "courts"
+	editDays      = "days"
+	editTime      = "time"
+	editExpiry    = "expiry"
+)
+
+// HandleSubscriptionField открывает шаг мастера для одного поля подписки (data: "<id>:<поле>")
+// Текущие значения уже отмечены, после шага - сразу итог подписки
+func (h *Handler) HandleSubscriptionField(cq *tgbotapi.CallbackQuery, data string) {
+	chatID := cq.Message.Chat.ID
+
+	id, field, _ := strings.Cut(data, ":")
+	sub, err := h.Store.Get(chatID, id)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Подписка не найдена"))
+		return
+	}
+
+	// Срок выбирается одной кнопкой, мастер для него не нужен
+	if field == editExpiry {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+		h.sendExpirySelection(chatID, sub)
+		return
+	}
+	if err := h.Store.SetEditing(chatID, sub.ID); err != nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.checkMode[chatID] = false
+	h.editField[chatID] = field
+	delete(userSelections, chatID)
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✏️ "+sub.DisplayName()))
+
+	switch field {
+	case editDistricts:
+		h.sendDistrictSelection(chatID)
+	case editCourts:
+		h.SendCourtsSelection(chatID)
+	case editDays:
+		h.SendDaysSelection(chatID)
+	case editTime:
+		h.SendTimeSelection(chatID)
+	default:
+		delete(h.editField, chatID)
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Неизвестное поле подписки."))
+	}
+}
+
+// continueWizard переходит к следующему шагу мастера, а при изменении одного поля из /my_subs - к итогу подписки
+func (h *Handler) continueWizard(chatID int64, next func(chatID int64)) {
+	if _, editing := h.editField[chatID]; editing {
+		h.SendSubscriptionSummary(chatID)
+		return
+	}
+	next(chatID)
+}
+
+// HandleSubscriptionEdit заново запускает мастер для выбранной подписки (значения сохраняются до перевыбора)
+func (h *Handler) HandleSubscriptionEdit(cq *tgbotapi.CallbackQuery, id string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.Get(chatID, id)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Подписка не найдена"))
+		return
+	}
+	if err := h.Store.SetEditing(chatID, sub.ID); err != nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.checkMode[chatID] = false
+	delete(h.editField, chatID)
+	delete(userSelections, chatID)
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✏️ "+sub.DisplayName()))
+	h.sendSportSelection(chatID)
+}
+
+// HandleSubscriptionDelete удаляет подписку из /my_subs или /cancel
+func (h *Handler) HandleSubscriptionDelete(cq *tgbotapi.CallbackQuery, id string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.Get(chatID, id)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Подписка уже удалена"))
+		return
+	}
+	if err := h.Store.Delete(chatID, id); err != nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при удалении подписки."))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "🗑 Удалено"))
+	h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Подписка «%s» удалена.\n\nОстальные подписки: /my_subs", sub.DisplayName())))
 }
 
 func (h *Handler) HandleCancel(msg *tgbotapi.Message) {
-	// Проверяем, есть ли подписка
-	sub, err := h.Store.Get(msg.Chat.ID)
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
 	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "⚠️ Ошибка при проверке подписки."))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при проверке подписки."))
 		return
 	}
 
-	if sub == nil {
-		h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "У тебя нет активной подписки.\n\nИспользуй /subscribe чтобы создать новую подписку."))
-		return
+	switch len(subs) {
+	case 0:
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "У тебя нет активной подписки.\n\nИспользуй /subscribe чтобы создать новую подписку."))
+	case 1:
+		// Единственную подписку удаляем сразу, как раньше
+		if err := h.Store.Delete(chatID, subs[0].ID); err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при удалении подписки."))
+			return
+		}
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "✅ Подписка успешно отменена.\n\nТы больше не будешь получать уведомления о доступных кортах.\n\nЧтобы создать новую подписку, используй /subscribe"))
+	default:
+		h.sendSubscriptionList(chatID, "Какую подписку отменить?")
 	}
+}
 
-	// Удаляем подписку
-	err = h.Store.Delete(msg.Chat.ID)
+// isSubscriptionComplete проверяет, что мастер настройки подписки пройден до конца
+func isSubscriptionComplete(sub *storage.Subscription) bool {
+	return len(sub.Districts) > 0 && len(sub.Courts) > 0 && (len(sub.Days) > 0 || len(sub.Dates) > 0) && sub.HasTimeWindows()
+}
+
+// formatSubscription форматирует параметры подписки для сообщений
+func formatSubscription(sub *storage.Subscription) string {
+	return fmt.Sprintf(
+		"🏅 Спорт: %s\n"+
+			"🌍 Город: %s\n"+
+			"🏙 Районы: %s\n"+
+			"🎾 Корты: %d выбрано\n"+
+			"🏟 Типы кортов: %s\n"+
+			"📅 Дни: %s\n"+
+			"⏰ Время: %s\n"+
+			"⏱ Длительность: %s\n"+
+			"💰 Цена: %s\n"+
+			"❌ Пропавшие слоты: %s",
+		types.SportName(sub.Sport),
+		types.CityByCode(sub.City).Name,
+		strings.Join(sub.Districts, ", "),
+		len(sub.Courts),
+		formatEnvironments(sub.Environments),
+		formatSubscriptionDays(sub),
+		formatTimeWindows(sub),
+		formatMinDuration(sub.MinDuration),
+		formatMaxPrice(sub.MaxPrice),
+		formatNotifyGone(sub.NotifyGone),
+	)
+}
+
+func formatNotifyGone(enabled bool) string {
+	if enabled {
+		return "зачеркивать"
+	}
+	return "не отмечать"
+}
+
+// HandleNotifyGone включает/выключает зачеркивание пропавших слотов в уже отправленных уведомлениях
+// Настройка общая для всех подписок чата
+func (h *Handler) HandleNotifyGone(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
 	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "⚠️ Ошибка при удалении подписки."))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		return
+	}
+	if len(subs) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "У тебя нет активной подписки.\n\nИспользуй /subscribe чтобы создать подписку."))
 		return
 	}
 
-	h.Bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "✅ Подписка успешно отменена.\n\nТы больше не будешь получать уведомления о доступных кортах.\n\nЧтобы создать новую подписку, используй /subscribe"))
+	// Если хоть в одной подписке включено - выключаем во всех
+	enable := true
+	for _, sub := range subs {
+		if sub.NotifyGone {
+			enable = false
+			break
+		}
+	}
+	for _, sub := range subs {
+		_, err := h.Store.UpdateSubscription(chatID, sub.ID, func(s *storage.Subscription) bool {
+			s.NotifyGone = enable
+			return true
+		})
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить настройку."))
+			return
+		}
+	}
+
+	text := "✅ Готово! Если слот из уведомления займут, я зачеркну его прямо в том сообщении.\n\nВыключить: /notify_gone"
+	if !enable {
+		text = "🔕 Пропавшие слоты больше не отмечаются.\n\nВключить снова: /notify_gone"
+	}
+	h.Bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// dayNames - короткие русские названия дней недели ("Mon" -> "Пн")
+var dayNames = map[string]string{
+	"Mon": "Пн", "Tue": "Вт", "Wed": "Ср", "Thu": "Чт",
+	"Fri": "Пт", "Sat": "Сб", "Sun": "Вс",
 }
 
 func formatDays(days []string) string {
@@ -107,10 +423,6 @@ func formatDays(days []string) string {
 	if len(days) == 7 {
 		return "все дни"
 	}
-	dayNames := map[string]string{
-		"Mon": "Пн", "Tue": "Вт", "Wed": "Ср", "Thu": "Чт",
-		"Fri": "Пт", "Sat": "Сб", "Sun": "Вс",
-	}
 	result := make([]string, 0, len(days))
 	for _, d := range days {
 		if name, ok := dayNames[d]; ok {
@@ -123,42 +435,38 @@ func formatDays(days []string) string {
 func (h *Handler) HandleGetCurrent(msg *tgbotapi.Message) {
 	chatID := msg.Chat.ID
 
-	// Проверяем наличие подписки
-	sub, err := h.Store.Get(chatID)
+	// Проверяем наличие подписок
+	subs, err := h.Store.ListChat(chatID)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
 		return
 	}
 
-	if sub == nil {
+	if len(subs) == 0 {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "У тебя нет активной подписки.\n\nИспользуй /subscribe чтобы создать подписку или /check для разовой проверки."))
 		return
 	}
 
-	// Проверяем что подписка полная (все параметры заданы)
-	if len(sub.Districts) == 0 || len(sub.Courts) == 0 || len(sub.Days) == 0 || sub.TimeFrom == "" || sub.TimeTo == "" {
-		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Твоя подписка неполная.\n\nИспользуй /subscribe чтобы завершить настройку."))
+	if h.Checker == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Сервис проверки временно недоступен."))
 		return
 	}
 
-	// Отправляем сообщение о начале проверки
-	text := fmt.Sprintf("🔍 Проверяю доступность кортов по твоей подписке...\n\n"+
-		"🏙 Районы: %s\n"+
-		"🎾 Корты: %d выбрано\n"+
-		"📅 Дни: %s\n"+
-		"⏰ Время: %s - %s",
-		strings.Join(sub.Districts, ", "),
-		len(sub.Courts),
-		formatDays(sub.Days),
-		sub.TimeFrom,
-		sub.TimeTo)
+	checked := 0
+	for _, sub := range subs {
+		// Неполные подписки (мастер не пройден) пропускаем
+		if !isSubscriptionComplete(sub) {
+			continue
+		}
 
-	h.Bot.Send(tgbotapi.NewMessage(chatID, text))
+		// Отправляем сообщение о начале проверки и запускаем проверку
+		text := fmt.Sprintf("🔍 Проверяю доступность кортов по подписке «%s»...\n\n", sub.DisplayName()) + formatSubscription(sub)
+		h.Bot.Send(tgbotapi.NewMessage(chatID, text))
+		h.Checker.CheckSubscriptionNow(sub)
+		checked++
+	}
 
-	// Запускаем проверку
-	if h.Checker != nil {
-		h.Checker.CheckSubscriptionNow(chatID)
-	} else {
-		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Сервис проверки временно недоступен."))
+	if checked == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Твоя подписка неполная.\n\nИзмени ее в /my_subs или создай новую через /subscribe."))
 	}
 }
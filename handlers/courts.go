@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 
-	"court-bot/parser"
+	"court-bot/storage"
+	"court-bot/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -37,7 +39,7 @@ func (h *Handler) SendCourtsSelection(chatID int64) {
 	sentMsg, _ := h.Bot.Send(loadingMsg)
 
 	// Получаем корты из kluby.org (с кешированием в Redis)
-	courts, err := parser.FetchCourts(sub.Districts, h.Store)
+	courts, err := h.Source.ListCourts(context.Background(), sub.City, sub.Sport, sub.Districts)
 	if err != nil {
 		log.Printf("⚠️ Error fetching courts: %v", err)
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке кортов. Попробуй позже."))
@@ -63,10 +65,13 @@ func (h *Handler) SendCourtsSelection(chatID int64) {
 	}
 	courtsIndexCache[chatID] = courtInfos
 
+	// После смены районов убираем выбранные клубы, которых в новых районах нет
+	h.pruneCourts(sub, courtInfos)
+
 	// Отправляем меню выбора кортов
 	msg := tgbotapi.NewMessage(chatID,
-		fmt.Sprintf("🎾 Шаг 2/4: Выбери корты\n\nРайоны: *%s*\nНайдено кортов: *%d*\n\nОтметь нужные корты:",
-			districtsText, len(courtInfos)))
+		fmt.Sprintf("🎾 Шаг 4/9: Выбери корты\n\nСпорт: *%s*\nРайоны: *%s*\nНайдено кортов: *%d*\n\nОтметь нужные корты:",
+			types.SportName(sub.Sport), districtsText, len(courtInfos)))
 	msg.ParseMode = "Markdown"
 	msg.ReplyMarkup = h.buildCourtsKeyboard(chatID, sub.Courts, courtInfos)
 	h.Bot.Send(msg)
@@ -144,11 +149,7 @@ func (h *Handler) HandleCourtToggle(cq *tgbotapi.CallbackQuery, courtIndexStr st
 		sub.Courts = append(sub.Courts, courtInfo.ID)
 	}
 
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
+	err = h.saveCurrent(chatID, sub)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор корта."))
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
@@ -178,5 +179,27 @@ func (h *Handler) HandleCourtsDone(cq *tgbotapi.CallbackQuery) {
 	}
 
 	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Корты выбраны"))
-	h.SendDaysSelection(chatID)
+	h.continueWizard(chatID, h.SendEnvironmentSelection)
+}
+
+// pruneCourts оставляет в подписке только клубы из списка выбора (невидимые отметки не сбросить кнопкой)
+func (h *Handler) pruneCourts(sub *storage.Subscription, available []CourtInfo) {
+	known := make(map[string]bool, len(available))
+	for _, c := range available {
+		known[c.ID] = true
+	}
+	kept := make([]string, 0, len(sub.Courts))
+	for _, id := range sub.Courts {
+		if known[id] {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) == len(sub.Courts) {
+		return
+	}
+
+	sub.Courts = kept
+	if err := h.saveCurrent(sub.ChatID, sub); err != nil {
+		log.Printf("⚠️ Failed to prune courts for %d: %v", sub.ChatID, err)
+	}
 }
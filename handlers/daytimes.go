@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"court-bot/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dayTimeDraft - настройка времени по дням недели (шаг 7 в режиме "по дням")
+// Окна копятся в черновике и попадают в подписку только после последнего дня:
+// брошенная на полпути настройка не оставляет подписку без времени
+type dayTimeDraft struct {
+	Days    []string                        // выбранные дни в порядке недели
+	Index   int                             // день, для которого сейчас выбирается время
+	From    string                          // выбранное "время от" своего окна
+	Windows map[string][]storage.TimeWindow // уже выбранные окна по дням
+}
+
+// dayTimeDrafts хранит настройку времени по дням по чатам (как userSelections)
+var dayTimeDrafts = make(map[int64]*dayTimeDraft)
+
+// HandleTimeMode - выбор между одним временем на все дни и своим временем для каждого дня
+func (h *Handler) HandleTimeMode(cq *tgbotapi.CallbackQuery, mode string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	if mode != "per_day" {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+		delete(dayTimeDrafts, chatID)
+		h.sendTimePresets(chatID, "⏰ Выбери время для всех дней\n\nСначала выбери удобный вариант или настрой свое время:")
+		return
+	}
+
+	draft := &dayTimeDraft{Windows: make(map[string][]storage.TimeWindow)}
+	for _, day := range weekDays {
+		if containsDay(sub.Days, day.Code) {
+			draft.Days = append(draft.Days, day.Code)
+		}
+	}
+	// Кнопка из старого сообщения: дни недели уже не выбраны (например, подписка перешла на конкретные даты)
+	if len(draft.Days) == 0 {
+		delete(dayTimeDrafts, chatID)
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Настройка времени устарела"))
+		h.SendTimeSelection(chatID)
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+	dayTimeDrafts[chatID] = draft
+	h.sendDayTimePresets(chatID)
+}
+
+// sendDayTimePresets предлагает время для текущего дня черновика
+func (h *Handler) sendDayTimePresets(chatID int64) {
+	draft := dayTimeDrafts[chatID]
+	day := draft.Days[draft.Index]
+
+	text := fmt.Sprintf("⏰ %s (%d/%d)\n\nВыбери время для этого дня:", weekDayName(day), draft.Index+1, len(draft.Days))
+	if windows := draft.Windows[day]; len(windows) > 0 {
+		text += "\n\nУже выбрано: " + formatWindows(windows)
+	}
+
+	keyboard := h.buildTimePresetsKeyboard()
+	// Для второго и следующих дней можно повторить предыдущий
+	if draft.Index > 0 && len(draft.Windows[day]) == 0 {
+		prev := draft.Days[draft.Index-1]
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Как в "+weekDayName(prev)+" ("+formatWindows(draft.Windows[prev])+")", "time_day:copy"),
+		))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	h.Bot.Send(msg)
+}
+
+// saveTimeWindow сохраняет выбранное окно времени: общее для всех дней или для текущего дня черновика
+func (h *Handler) saveTimeWindow(cq *tgbotapi.CallbackQuery, sub *storage.Subscription, from, to string) {
+	chatID := cq.Message.Chat.ID
+
+	draft := dayTimeDrafts[chatID]
+	if draft == nil {
+		sub.TimeFrom, sub.TimeTo = from, to
+		sub.DayWindows = nil
+		if err := h.saveCurrent(chatID, sub); err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+			return
+		}
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Время выбрано"))
+		h.continueWizard(chatID, h.SendDurationSelection)
+		return
+	}
+
+	day := draft.Days[draft.Index]
+	draft.Windows[day] = append(draft.Windows[day], storage.TimeWindow{From: from, To: to})
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Время выбрано"))
+	h.sendDayTimeNext(chatID)
+}
+
+// sendDayTimeNext предлагает добавить еще окно в этот день или перейти к следующему
+func (h *Handler) sendDayTimeNext(chatID int64) {
+	draft := dayTimeDrafts[chatID]
+	day := draft.Days[draft.Index]
+
+	next := "➡️ Следующий день"
+	if draft.Index == len(draft.Days)-1 {
+		next = "✅ Готово"
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s: %s", weekDayName(day), formatWindows(draft.Windows[day])))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Еще окно", "time_day:more"),
+		tgbotapi.NewInlineKeyboardButtonData(next, "time_day:next"),
+	))
+	h.Bot.Send(msg)
+}
+
+// HandleTimeDay - действия настройки времени по дням: еще окно, следующий день, как в предыдущий день
+func (h *Handler) HandleTimeDay(cq *tgbotapi.CallbackQuery, action string) {
+	chatID := cq.Message.Chat.ID
+
+	draft := dayTimeDrafts[chatID]
+	if draft == nil || draft.Index >= len(draft.Days) {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Настройка времени устарела"))
+		return
+	}
+
+	switch action {
+	case "more":
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+		h.sendDayTimePresets(chatID)
+	case "copy":
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+		if draft.Index == 0 {
+			return
+		}
+		day, prev := draft.Days[draft.Index], draft.Days[draft.Index-1]
+		draft.Windows[day] = append([]storage.TimeWindow(nil), draft.Windows[prev]...)
+		h.sendDayTimeNext(chatID)
+	case "next":
+		if len(draft.Windows[draft.Days[draft.Index]]) == 0 {
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Выбери время для этого дня"))
+			return
+		}
+		draft.Index++
+		if draft.Index < len(draft.Days) {
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+			h.sendDayTimePresets(chatID)
+			return
+		}
+		h.finishDayTimes(cq, draft)
+	}
+}
+
+// finishDayTimes сохраняет окна всех дней в подписку одним изменением: общего окна в этом режиме нет
+func (h *Handler) finishDayTimes(cq *tgbotapi.CallbackQuery, draft *dayTimeDraft) {
+	chatID := cq.Message.Chat.ID
+	delete(dayTimeDrafts, chatID)
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Настройка времени устарела"))
+		return
+	}
+	sub.DayWindows = draft.Windows
+	sub.TimeFrom, sub.TimeTo = "", ""
+	if err := h.saveCurrent(chatID, sub); err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Время выбрано"))
+	h.continueWizard(chatID, h.SendDurationSelection)
+}
+
+// saveCurrent сохраняет настраиваемую подписку (или разовую проверку в режиме /check)
+// Подписка сохраняется через SaveSettings: пауза и срок, измененные за время шага мастера, не затираются
+// Новая подписка (еще без ID) просто создается
+func (h *Handler) saveCurrent(chatID int64, sub *storage.Subscription) error {
+	if h.checkMode[chatID] {
+		return h.Store.SaveCheck(sub)
+	}
+	if sub.ID == "" {
+		return h.Store.Save(sub)
+	}
+	return h.Store.SaveSettings(sub)
+}
+
+// continueToTime продолжает мастер после выбора дней или дат: если не у всех дней есть время,
+// выбор времени показывается даже при редактировании одного поля из /my_subs
+func (h *Handler) continueToTime(chatID int64, sub *storage.Subscription) {
+	if !sub.HasTimeWindows() {
+		h.SendTimeSelection(chatID)
+		return
+	}
+	h.continueWizard(chatID, h.SendTimeSelection)
+}
+
+// formatTimeWindows описывает время подписки: "18:00 - 21:00" или по дням "Пн, Вт: 18:00-21:00; Сб: 09:00-22:00"
+func formatTimeWindows(sub *storage.Subscription) string {
+	if len(sub.DayWindows) == 0 {
+		return sub.TimeFrom + " - " + sub.TimeTo
+	}
+
+	// Соседние по списку дни с одинаковыми окнами объединяем
+	var parts []string
+	var group []string
+	last := ""
+	flush := func() {
+		if len(group) > 0 {
+			parts = append(parts, strings.Join(group, ", ")+": "+last)
+		}
+	}
+	for _, day := range weekDays {
+		windows := sub.WindowsFor(day.Code)
+		if len(windows) == 0 || !containsDay(sub.Days, day.Code) {
+			continue
+		}
+		text := formatWindows(windows)
+		if text != last {
+			flush()
+			group, last = nil, text
+		}
+		group = append(group, dayNames[day.Code])
+	}
+	flush()
+	return strings.Join(parts, "; ")
+}
+
+// formatWindows печатает окна одного дня: "09:00-12:00, 17:00-22:00"
+func formatWindows(windows []storage.TimeWindow) string {
+	parts := make([]string, len(windows))
+	for i, w := range windows {
+		parts[i] = w.From + "-" + w.To
+	}
+	return strings.Join(parts, ", ")
+}
+
+func containsDay(days []string, day string) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekDayName - полное название дня недели ("Sat" -> "Суббота")
+func weekDayName(code string) string {
+	for _, d := range weekDays {
+		if d.Code == code {
+			return d.Name
+		}
+	}
+	return code
+}
@@ -1,49 +1,136 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"time"
 
-	"court-bot/parser"
-	"court-bot/storage"
+	"court-bot/types"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// districts будет загружен из kluby.org при инициализации
-var districts []string
+// districtsCache - списки районов по городам и видам спорта ("город:спорт")
+// Теннис в Варшаве загружается при инициализации, остальные - при первом выборе
+var districtsCache = make(map[string][]string)
+
+// fallbackDistricts используется если kluby.org недоступен (только для Варшавы)
+var fallbackDistricts = []string{
+	"Mokotów", "Wola", "Ursynów", "Śródmieście", "Ochota",
+	"Żoliborz", "Praga Południe", "Praga Północ", "Bielany",
+}
+
+// districtsRetryAfter - сколько не повторять загрузку районов после ошибки kluby.org
+// (иначе каждое нажатие в мастере снова ходит на сайт и снова получает ошибку)
+const districtsRetryAfter = 2 * time.Minute
+
+// districtsFailed - когда загрузка районов не удалась ("город:спорт")
+var districtsFailed = make(map[string]time.Time)
+
+func districtsKey(city, sport string) string {
+	return types.CityOrDefault(city) + ":" + types.SportOrDefault(sport)
+}
 
 // InitDistricts загружает список районов Варшавы из kluby.org (с кешированием в Redis)
-func InitDistricts(store *storage.Storage) error {
-	var err error
-	districts, err = parser.FetchWarsawDistricts(store)
+func InitDistricts(source CourtSource) error {
+	key := districtsKey(types.CityWarsaw, types.SportTennis)
+	list, err := source.ListDistricts(context.Background(), types.CityWarsaw, types.SportTennis)
 	if err != nil {
 		log.Printf("⚠️ Failed to fetch districts from kluby.org: %v", err)
 		// Fallback на жестко закодированный список
-		districts = []string{
-			"Mokotów", "Wola", "Ursynów", "Śródmieście", "Ochota",
-			"Żoliborz", "Praga Południe", "Praga Północ", "Bielany",
-		}
-		log.Printf("Using fallback district list (%d districts)", len(districts))
+		districtsCache[key] = fallbackDistricts
+		log.Printf("Using fallback district list (%d districts)", len(fallbackDistricts))
+		return err
+	}
+	districtsCache[key] = list
+	return nil
+}
+
+// districtsFor возвращает районы города для вида спорта, загружая их при первом обращении
+func (h *Handler) districtsFor(city, sport string) []string {
+	key := districtsKey(city, sport)
+	if list, ok := districtsCache[key]; ok {
+		return list
+	}
+
+	fallback := []string(nil)
+	if types.CityOrDefault(city) == types.CityWarsaw {
+		fallback = fallbackDistricts
+	}
+	if at, ok := districtsFailed[key]; ok && time.Since(at) < districtsRetryAfter {
+		return fallback
+	}
+
+	list, err := h.Source.ListDistricts(context.Background(), types.CityOrDefault(city), types.SportOrDefault(sport))
+	if err != nil || len(list) == 0 {
+		log.Printf("⚠️ Failed to fetch districts for %s: %v", key, err)
+		districtsFailed[key] = time.Now()
+		return fallback
+	}
+	delete(districtsFailed, key)
+	districtsCache[key] = list
+	return list
+}
+
+// districtsRetryIn - через сколько можно снова попробовать загрузить районы (0 - можно сейчас)
+func districtsRetryIn(city, sport string) time.Duration {
+	at, ok := districtsFailed[districtsKey(city, sport)]
+	if !ok {
+		return 0
 	}
-	return err
+	if left := districtsRetryAfter - time.Since(at); left > 0 {
+		return left
+	}
+	return 0
 }
 
 // userSelections хранит временные выборы пользователей (district checkboxes)
 var userSelections = make(map[int64]map[string]bool)
 
 func (h *Handler) sendDistrictSelection(chatID int64) {
+	// Отмечаем районы, уже сохраненные в подписке (при изменении подписки)
 	if _, ok := userSelections[chatID]; !ok {
 		userSelections[chatID] = make(map[string]bool)
+		if sub, err := h.Store.GetCurrent(chatID); err == nil && sub != nil {
+			for _, district := range sub.Districts {
+				userSelections[chatID][district] = true
+			}
+		}
+	}
+
+	city, sport := h.currentCitySport(chatID)
+	if len(h.districtsFor(city, sport)) == 0 {
+		text := fmt.Sprintf("⚠️ Не удалось загрузить районы: %s, %s. kluby.org сейчас не отвечает.", types.CityByCode(city).Name, types.SportName(sport))
+		if left := districtsRetryIn(city, sport); left > 0 {
+			text += fmt.Sprintf("\n\nПопробуй снова через %d мин.", max(1, int(left.Round(time.Minute)/time.Minute)))
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Попробовать снова", "districts_retry"),
+		))
+		h.Bot.Send(msg)
+		return
 	}
 
-	msg := tgbotapi.NewMessage(chatID, "🏙 Шаг 1/4: Выбери районы Варшавы\n\nНажимай на районы, чтобы отметить нужные:")
-	msg.ReplyMarkup = h.buildDistrictsKeyboard(chatID)
+	text := fmt.Sprintf("🏙 Шаг 3/9: Выбери районы (%s)\n\nНажимай на районы, чтобы отметить нужные:", types.CityByCode(city).Name)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = h.buildDistrictsKeyboard(chatID, city, sport)
 	h.Bot.Send(msg)
 }
 
-func (h *Handler) buildDistrictsKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+// currentCitySport возвращает город и вид спорта из настраиваемой подписки
+func (h *Handler) currentCitySport(chatID int64) (string, string) {
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		return types.CityWarsaw, types.SportTennis
+	}
+	return types.CityOrDefault(sub.City), types.SportOrDefault(sub.Sport)
+}
+
+func (h *Handler) buildDistrictsKeyboard(chatID int64, city, sport string) tgbotapi.InlineKeyboardMarkup {
 	var rows [][]tgbotapi.InlineKeyboardButton
-	for _, d := range districts {
+	for _, d := range h.districtsFor(city, sport) {
 		selected := userSelections[chatID][d]
 		label := d
 		if selected {
@@ -67,11 +154,18 @@ func (h *Handler) HandleDistrictToggle(cq *tgbotapi.CallbackQuery, district stri
 	}
 	userSelections[chatID][district] = !userSelections[chatID][district]
 
-	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, h.buildDistrictsKeyboard(chatID))
+	city, sport := h.currentCitySport(chatID)
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, h.buildDistrictsKeyboard(chatID, city, sport))
 	h.Bot.Send(edit)
 	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Обновлено"))
 }
 
+// HandleDistrictsRetry повторно показывает выбор районов после ошибки загрузки
+func (h *Handler) HandleDistrictsRetry(cq *tgbotapi.CallbackQuery) {
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+	h.sendDistrictSelection(cq.Message.Chat.ID)
+}
+
 func (h *Handler) HandleDistrictsDone(cq *tgbotapi.CallbackQuery) {
 	chatID := cq.Message.Chat.ID
 
@@ -94,16 +188,16 @@ func (h *Handler) HandleDistrictsDone(cq *tgbotapi.CallbackQuery) {
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
 		return
 	}
+	// Кнопка из старого сообщения: настраиваемой подписки уже нет. Новую запись не создаем -
+	// без SetEditing она осталась бы в /my_subs без города и вида спорта
 	if sub == nil {
-		sub = &storage.Subscription{ChatID: chatID}
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Выбор устарел"))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Выбор устарел. Начни заново: /subscribe или /check"))
+		return
 	}
 
 	sub.Districts = selectedDistricts
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
+	err = h.saveCurrent(chatID, sub)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
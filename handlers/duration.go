@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// durationPresets - варианты минимальной непрерывной длительности в минутах
+var durationPresets = []int{60, 90, 120}
+
+// Шаг 8: Выбор минимальной длительности
+func (h *Handler) SendDurationSelection(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "⏱ Шаг 8/9: Минимальная длительность\n\nСоседние свободные слоты на одном корте объединяются. Сколько минут подряд тебе нужно?")
+	msg.ReplyMarkup = h.buildDurationKeyboard()
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildDurationKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, d := range durationPresets {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(formatMinDuration(d), fmt.Sprintf("min_duration:%d", d)))
+	}
+
+	anyBtn := tgbotapi.NewInlineKeyboardButtonData("Любая", "min_duration:0")
+	return tgbotapi.NewInlineKeyboardMarkup(row, tgbotapi.NewInlineKeyboardRow(anyBtn))
+}
+
+// Обработка выбора минимальной длительности
+func (h *Handler) HandleMinDuration(cq *tgbotapi.CallbackQuery, minutesStr string) {
+	chatID := cq.Message.Chat.ID
+
+	minutes, err := strconv.Atoi(minutesStr)
+	if err != nil || minutes < 0 {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Неверная длительность"))
+		return
+	}
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	sub.MinDuration = minutes
+	err = h.saveCurrent(chatID, sub)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить длительность."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Длительность выбрана"))
+	h.SendPriceSelection(chatID)
+}
+
+func formatMinDuration(minutes int) string {
+	if minutes <= 0 {
+		return "любая"
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("от %d ч", minutes/60)
+	}
+	return fmt.Sprintf("от %d мин", minutes)
+}
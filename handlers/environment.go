@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"strings"
+
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Шаг 5: Выбор типа кортов (крытые, балон, открытые)
+func (h *Handler) SendEnvironmentSelection(chatID int64) {
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "🏟 Шаг 5/9: Выбери типы кортов\n\nОткрытые корты по умолчанию не отслеживаются — отметь их, если хочешь играть на улице:")
+	msg.ReplyMarkup = h.buildEnvironmentsKeyboard(sub.Environments)
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildEnvironmentsKeyboard(selectedEnvs []string) tgbotapi.InlineKeyboardMarkup {
+	if len(selectedEnvs) == 0 {
+		selectedEnvs = types.DefaultEnvironments
+	}
+	selected := make(map[string]bool)
+	for _, e := range selectedEnvs {
+		selected[e] = true
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, env := range types.Environments {
+		label := env.Name
+		if selected[env.Code] {
+			label = "✅ " + env.Name
+		}
+		btn := tgbotapi.NewInlineKeyboardButtonData(label, "toggle_env:"+env.Code)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+
+	done := tgbotapi.NewInlineKeyboardButtonData("✅ Готово", "envs_done")
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(done))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (h *Handler) HandleEnvironmentToggle(cq *tgbotapi.CallbackQuery, env string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	// Пустой список означает значения по умолчанию - разворачиваем их перед изменением
+	current := sub.Environments
+	if len(current) == 0 {
+		current = types.DefaultEnvironments
+	}
+
+	// Toggle выбранного типа
+	found := false
+	newEnvs := make([]string, 0, len(current))
+	for _, e := range current {
+		if e == env {
+			found = true
+		} else {
+			newEnvs = append(newEnvs, e)
+		}
+	}
+	if !found {
+		newEnvs = append(newEnvs, env)
+	}
+	if len(newEnvs) == 0 {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Нужен хотя бы один тип кортов"))
+		return
+	}
+	sub.Environments = newEnvs
+
+	err = h.saveCurrent(chatID, sub)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, h.buildEnvironmentsKeyboard(sub.Environments))
+	h.Bot.Send(edit)
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Обновлено"))
+}
+
+func (h *Handler) HandleEnvironmentsDone(cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Типы кортов выбраны"))
+	h.SendDaysSelection(chatID)
+}
+
+func formatEnvironments(envs []string) string {
+	if len(envs) == 0 {
+		envs = types.DefaultEnvironments
+	}
+	result := make([]string, 0, len(envs))
+	for _, e := range envs {
+		result = append(result, types.EnvironmentName(e))
+	}
+	return strings.Join(result, ", ")
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	insightsPeriod     = 30 * 24 * time.Hour // за какой период считаем статистику
+	insightsMinSamples = 5                   // меньше записей по клубу - "мало данных"
+	primeTimeFrom      = 17                  // прайм-тайм: слоты, начинающиеся с 17:00
+	primeTimeTo        = 21                  // ... и до 21:00
+)
+
+// clubInsights - статистика доступности по одному клубу
+type clubInsights struct {
+	Name       string
+	Samples    int
+	TopHours   []int          // часы, в которые чаще всего появляются свободные слоты
+	TopDays    []time.Weekday // дни недели, в которые чаще всего появляются свободные слоты
+	FreeFor    time.Duration  // медиана: сколько слот остается свободным до брони
+	Booked     int            // сколько слотов забронировали до начала
+	PrimeLead  time.Duration  // медиана: за сколько до начала впервые виден слот прайм-тайма
+	PrimeCount int            // сколько слотов прайм-тайма учтено
+	Reappeared int            // сколько слотов освобождались повторно (отмены)
+	hourCounts map[int]int
+	dayCounts  map[time.Weekday]int
+	freeFor    []time.Duration
+	primeLeads []time.Duration
+}
+
+// HandleInsights показывает, когда в клубах подписки обычно появляются свободные слоты
+func (h *Handler) HandleInsights(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		return
+	}
+	withCourts := make([]*storage.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		if len(sub.Courts) > 0 {
+			withCourts = append(withCourts, sub)
+		}
+	}
+	if len(withCourts) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "Аналитика строится по клубам подписки.\n\nИспользуй /subscribe чтобы выбрать клубы."))
+		return
+	}
+
+	now := time.Now()
+	records, err := h.Store.ListLifecycles(now.Add(-insightsPeriod), now.Add(14*24*time.Hour))
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке истории слотов."))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("📊 Когда освобождаются корты в твоих клубах (за 30 дней)\n")
+	// Один клуб может быть в нескольких подписках - показываем его один раз
+	shown := make(map[string]bool)
+	for _, sub := range withCourts {
+		sport := types.SportOrDefault(sub.Sport)
+		stats := buildInsights(records, sport, sub.Courts, types.CityLocation(sub.City))
+		for _, courtID := range sub.Courts {
+			if shown[sport+":"+courtID] {
+				continue
+			}
+			shown[sport+":"+courtID] = true
+
+			st := stats[courtID]
+			if st == nil || st.Samples < insightsMinSamples {
+				name := courtID
+				if st != nil {
+					name = st.Name
+				}
+				fmt.Fprintf(&b, "\n🎾 %s\nПока мало данных — загляни через несколько дней.\n", name)
+				continue
+			}
+			b.WriteString("\n" + formatClubInsights(st))
+		}
+	}
+	b.WriteString("\nℹ️ ")
+	if h.Checker != nil {
+		b.WriteString("Бот " + h.Checker.PollSummary() + ", поэтому время появления приблизительное. ")
+	}
+	b.WriteString("Слоты, которые уже были свободны, когда бот впервые открыл график на эту дату, во время появления не входят.")
+
+	h.Bot.Send(tgbotapi.NewMessage(chatID, b.String()))
+}
+
+// buildInsights собирает статистику по клубам из истории слотов
+func buildInsights(records []types.SlotLifecycle, sport string, courts []string, loc *time.Location) map[string]*clubInsights {
+	wanted := make(map[string]bool, len(courts))
+	for _, id := range courts {
+		wanted[id] = true
+	}
+
+	stats := make(map[string]*clubInsights)
+	for _, rec := range records {
+		slot := rec.Slot
+		if !wanted[slot.ClubID] || types.SportOrDefault(slot.Sport) != sport {
+			continue
+		}
+		st := stats[slot.ClubID]
+		if st == nil {
+			st = &clubInsights{Name: slot.ClubName, hourCounts: make(map[int]int), dayCounts: make(map[time.Weekday]int)}
+			stats[slot.ClubID] = st
+		}
+
+		start, err := time.ParseInLocation("2006-01-02 15:04", slot.Date+" "+slot.Time, loc)
+		if err != nil {
+			continue
+		}
+
+		// Слот был свободен уже при первой проверке страницы (дата только вошла в горизонт или клуб
+		// только добавили в подписку): момент его появления неизвестен. Учитываем только повторные появления
+		if rec.Preexisting && rec.Appearances == 1 {
+			continue
+		}
+		st.Samples++
+
+		// Когда слот появился (первое появление и последнее, если он освобождался повторно)
+		if !rec.Preexisting {
+			appeared := rec.FirstSeen.In(loc)
+			st.hourCounts[appeared.Hour()]++
+			st.dayCounts[appeared.Weekday()]++
+		}
+		if rec.Appearances > 1 {
+			st.Reappeared++
+			last := rec.AppearedAt.In(loc)
+			st.hourCounts[last.Hour()]++
+			st.dayCounts[last.Weekday()]++
+		}
+
+		// Слот пропал до начала - считаем, что его забронировали
+		if !rec.Available() && rec.DisappearedAt.Before(start) {
+			st.freeFor = append(st.freeFor, rec.DisappearedAt.Sub(rec.AppearedAt))
+		}
+
+		if !rec.Preexisting && start.Hour() >= primeTimeFrom && start.Hour() < primeTimeTo {
+			if lead := start.Sub(rec.FirstSeen); lead > 0 {
+				st.primeLeads = append(st.primeLeads, lead)
+			}
+		}
+	}
+
+	for _, st := range stats {
+		st.TopHours = topKeys(st.hourCounts, 3)
+		st.TopDays = topKeys(st.dayCounts, 2)
+		st.Booked = len(st.freeFor)
+		st.FreeFor = median(st.freeFor)
+		st.PrimeCount = len(st.primeLeads)
+		st.PrimeLead = median(st.primeLeads)
+	}
+	return stats
+}
+
+func formatClubInsights(st *clubInsights) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎾 %s\n", st.Name)
+
+	hours := make([]string, len(st.TopHours))
+	for i, hour := range st.TopHours {
+		hours[i] = fmt.Sprintf("%02d:00–%02d:00", hour, (hour+1)%24)
+	}
+	fmt.Fprintf(&b, "🕐 Слоты чаще появляются: %s\n", strings.Join(hours, ", "))
+
+	days := make([]string, len(st.TopDays))
+	for i, d := range st.TopDays {
+		days[i] = dayNames[d.String()[:3]]
+	}
+	fmt.Fprintf(&b, "📅 Чаще всего по дням: %s\n", strings.Join(days, ", "))
+
+	if st.Booked > 0 {
+		fmt.Fprintf(&b, "⏳ Свободный слот живет ~%s до брони (%d бронирований)\n", formatSpan(st.FreeFor), st.Booked)
+	}
+	if st.PrimeCount > 0 {
+		fmt.Fprintf(&b, "🌆 Прайм-тайм (%d:00–%d:00) виден за ~%s до начала\n", primeTimeFrom, primeTimeTo, formatSpan(st.PrimeLead))
+	}
+	if st.Reappeared > 0 {
+		fmt.Fprintf(&b, "🔁 Освобождались после брони: %d\n", st.Reappeared)
+	}
+	fmt.Fprintf(&b, "📈 Слотов в выборке: %d\n", st.Samples)
+	return b.String()
+}
+
+// formatSpan форматирует длительность в минутах, часах или днях
+func formatSpan(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%d мин", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.0f ч", d.Hours())
+	default:
+		return fmt.Sprintf("%.0f дн.", d.Hours()/24)
+	}
+}
+
+// topKeys возвращает до n ключей с наибольшими счетчиками (при равенстве - меньший ключ первым)
+func topKeys[K int | time.Weekday](counts map[K]int, n int) []K {
+	keys := make([]K, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
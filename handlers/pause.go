@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// expiryOptions - сроки подписки на выбор (в днях, 0 = бессрочно)
+var expiryOptions = []struct {
+	Days  int
+	Label string
+}{
+	{3, "3 дня"},
+	{7, "Неделя"},
+	{14, "2 недели"},
+	{30, "Месяц"},
+	{0, "♾ Без срока"},
+}
+
+// HandlePause приостанавливает уведомления всех подписок чата: /pause [7 | 7 дней | до 01.12 | 2025-12-01]
+// Без аргумента - до /resume
+func (h *Handler) HandlePause(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписок."))
+		return
+	}
+	if len(subs) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "У тебя нет активной подписки.\n\nИспользуй /subscribe чтобы создать подписку."))
+		return
+	}
+
+	// Срок паузы считается по часовому поясу города каждой подписки: "до 01.12" - до полуночи в ее городе
+	now := time.Now()
+	untils := make(map[string]time.Time, len(subs))
+	for _, sub := range subs {
+		until, err := parsePauseUntil(msg.CommandArguments(), now.In(types.CityLocation(sub.City)))
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не понял срок паузы.\n\nПримеры: /pause, /pause 7, /pause до 01.12"))
+			return
+		}
+		untils[sub.ID] = until
+	}
+
+	for _, sub := range subs {
+		until := untils[sub.ID]
+		_, err := h.Store.UpdateSubscription(chatID, sub.ID, func(s *storage.Subscription) bool {
+			s.Paused = true
+			s.PausedUntil = until
+			return true
+		})
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось поставить подписку на паузу."))
+			return
+		}
+	}
+
+	text := "⏸ Уведомления на паузе. Настройки подписок сохранены.\n\nВозобновить: /resume"
+	if until := untils[subs[0].ID]; !until.IsZero() {
+		text = fmt.Sprintf("⏸ Уведомления на паузе до %s — потом возобновятся сами.\n\nВозобновить раньше: /resume", until.Format("02.01.2006"))
+	}
+	h.Bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// HandleResume снимает паузу со всех подписок чата
+func (h *Handler) HandleResume(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	subs, err := h.Store.ListChat(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписок."))
+		return
+	}
+
+	resumed := 0
+	now := time.Now()
+	for _, sub := range subs {
+		// Пауза с истекшим сроком уже не действует: ее флаг снимет updateLifetimes
+		if !sub.IsPaused(now) {
+			continue
+		}
+		updated, err := h.Store.UpdateSubscription(chatID, sub.ID, func(s *storage.Subscription) bool {
+			s.Paused = false
+			s.PausedUntil = time.Time{}
+			return true
+		})
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось возобновить подписку."))
+			return
+		}
+		if updated != nil {
+			resumed++
+		}
+	}
+
+	if resumed == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "Подписки и так активны.\n\nПоставить на паузу: /pause"))
+		return
+	}
+	h.Bot.Send(tgbotapi.NewMessage(chatID, "▶️ Уведомления снова включены."))
+}
+
+// HandleSubscriptionPause ставит на паузу или возобновляет одну подписку из /my_subs
+func (h *Handler) HandleSubscriptionPause(cq *tgbotapi.CallbackQuery, id string) {
+	chatID := cq.Message.Chat.ID
+
+	sub, err := h.Store.Get(chatID, id)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Подписка не найдена"))
+		return
+	}
+
+	paused := sub.IsPaused(time.Now())
+	sub, err = h.Store.UpdateSubscription(chatID, id, func(s *storage.Subscription) bool {
+		s.Paused = !paused
+		s.PausedUntil = time.Time{}
+		return true
+	})
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	answer := "⏸ На паузе"
+	if paused {
+		answer = "▶️ Возобновлено"
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, answer))
+	h.refreshSubscriptionCard(cq, sub)
+}
+
+// sendExpirySelection предлагает срок, после которого подписка закончится сама
+func (h *Handler) sendExpirySelection(chatID int64, sub *storage.Subscription) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, opt := range expiryOptions {
+		btn := tgbotapi.NewInlineKeyboardButtonData(opt.Label, fmt.Sprintf("sub_expire:%s:%d", sub.ID, opt.Days))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+
+	text := fmt.Sprintf("⌛ Сколько еще проверять подписку «%s»?\n\nПо окончании срока подписка перестанет присылать уведомления, настройки сохранятся.", sub.DisplayName())
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.Bot.Send(msg)
+}
+
+// HandleSubscriptionExpiry задает срок подписки (data: "<id>:<дней>", 0 = бессрочно)
+func (h *Handler) HandleSubscriptionExpiry(cq *tgbotapi.CallbackQuery, data string) {
+	chatID := cq.Message.Chat.ID
+
+	id, daysStr, _ := strings.Cut(data, ":")
+	days, err := strconv.Atoi(daysStr)
+	if err != nil || days < 0 {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Неверный срок"))
+		return
+	}
+	sub, err := h.Store.Get(chatID, id)
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Подписка не найдена"))
+		return
+	}
+
+	var expires time.Time
+	if days > 0 {
+		// Подписка действует до конца последнего дня по времени города: "3 дня" - сегодня и еще два дня
+		now := time.Now().In(types.CityLocation(sub.City))
+		expires = startOfDay(now).AddDate(0, 0, days)
+	}
+	sub, err = h.Store.UpdateSubscription(chatID, id, func(s *storage.Subscription) bool {
+		s.SetExpiry(expires)
+		return true
+	})
+	if err != nil || sub == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Срок сохранен"))
+	text := fmt.Sprintf("✅ Подписка «%s» теперь бессрочная.", sub.DisplayName())
+	if !expires.IsZero() {
+		text = fmt.Sprintf("✅ Подписка «%s» действует %s. За сутки до конца напомню.", sub.DisplayName(), formatExpiry(sub))
+	}
+	edit := tgbotapi.NewEditMessageText(chatID, cq.Message.MessageID, text)
+	h.Bot.Send(edit)
+}
+
+// refreshSubscriptionCard перерисовывает карточку подписки из /my_subs после изменения
+func (h *Handler) refreshSubscriptionCard(cq *tgbotapi.CallbackQuery, sub *storage.Subscription) {
+	edit := tgbotapi.NewEditMessageTextAndMarkup(cq.Message.Chat.ID, cq.Message.MessageID, subscriptionCard(sub), subscriptionKeyboard(sub))
+	h.Bot.Send(edit)
+}
+
+// parsePauseUntil разбирает срок паузы: "" - бессрочно, "7" / "7 дней" / "for 7 days" - на N дней,
+// "до 01.12" / "until 2025-12-01" - до начала указанной даты
+func parsePauseUntil(args string, now time.Time) (time.Time, error) {
+	args = strings.ToLower(strings.TrimSpace(args))
+	for _, prefix := range []string{"until", "for", "до", "на"} {
+		args = strings.TrimSpace(strings.TrimPrefix(args, prefix+" "))
+	}
+	if args == "" {
+		return time.Time{}, nil
+	}
+
+	fields := strings.Fields(args)
+	if n, err := strconv.Atoi(strings.TrimRight(fields[0], "dд")); err == nil {
+		if n < 1 || n > 365 {
+			return time.Time{}, fmt.Errorf("pause days out of range: %d", n)
+		}
+		return now.AddDate(0, 0, n), nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "02.01.2006", "02.01"} {
+		d, err := time.ParseInLocation(layout, fields[0], now.Location())
+		if err != nil {
+			continue
+		}
+		if layout == "02.01" {
+			d = time.Date(now.Year(), d.Month(), d.Day(), 0, 0, 0, 0, now.Location())
+			// Дата без года уже прошла в этом году - значит следующий год
+			if !d.After(now) {
+				d = d.AddDate(1, 0, 0)
+			}
+		}
+		if !d.After(now) {
+			return time.Time{}, fmt.Errorf("pause date %s is in the past", fields[0])
+		}
+		return d, nil
+	}
+	return time.Time{}, fmt.Errorf("unknown pause duration %q", args)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// formatExpiry печатает срок подписки по времени ее города: "по 01.12 включительно"
+func formatExpiry(sub *storage.Subscription) string {
+	expires := sub.ExpiresAt.In(types.CityLocation(sub.City))
+	return "по " + expires.Add(-time.Second).Format("02.01") + " включительно"
+}
+
+// formatLifetime описывает паузу и срок подписки ("" если подписка бессрочная и активна)
+func formatLifetime(sub *storage.Subscription, now time.Time) string {
+	var lines []string
+	switch {
+	case sub.IsPaused(now) && sub.PausedUntil.IsZero():
+		lines = append(lines, "⏸ На паузе")
+	case sub.IsPaused(now):
+		lines = append(lines, "⏸ На паузе до "+sub.PausedUntil.In(types.CityLocation(sub.City)).Format("02.01"))
+	}
+	switch {
+	case sub.DatesPassed(now):
+		lines = append(lines, "📅 Все даты прошли")
+	case sub.IsExpired(now):
+		lines = append(lines, "⌛ Срок закончился")
+	case !sub.ExpiresAt.IsZero():
+		lines = append(lines, "⌛ Действует "+formatExpiry(sub))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePauseUntil(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	now := time.Date(2025, 11, 10, 15, 30, 0, 0, loc)
+	day := func(month time.Month, d, year int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		args    string
+		want    time.Time
+		wantErr bool
+	}{
+		{args: "", want: time.Time{}},
+		{args: "7", want: now.AddDate(0, 0, 7)},
+		{args: "7 дней", want: now.AddDate(0, 0, 7)},
+		{args: "на 3 дня", want: now.AddDate(0, 0, 3)},
+		{args: "for 7 days", want: now.AddDate(0, 0, 7)},
+		{args: "7d", want: now.AddDate(0, 0, 7)},
+		{args: "до 01.12", want: day(time.December, 1, 2025)},
+		{args: "until 2025-12-01", want: day(time.December, 1, 2025)},
+		{args: "до 01.12.2025", want: day(time.December, 1, 2025)},
+		{args: "до 01.11", want: day(time.November, 1, 2026)},  // без года и уже прошла - следующий год
+		{args: "до 10.11", want: day(time.November, 10, 2026)}, // сегодняшнее начало дня уже прошло
+		{args: "0", wantErr: true},
+		{args: "400", wantErr: true},
+		{args: "до 2025-11-01", wantErr: true},
+		{args: "завтра", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePauseUntil(tt.args, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePauseUntil(%q) = %v, want error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parsePauseUntil(%q) = %v, %v; want %v", tt.args, got, err, tt.want)
+		}
+	}
+}
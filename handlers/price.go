@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pricePresets - варианты максимальной цены за час (PLN), 0 = без ограничения
+var pricePresets = []int{60, 80, 100, 120, 150}
+
+// Шаг 9: Выбор максимальной цены за час
+func (h *Handler) SendPriceSelection(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "💰 Шаг 9/9: Максимальная цена за час\n\nСлоты дороже выбранной цены не будут присылаться:")
+	msg.ReplyMarkup = h.buildPriceKeyboard()
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildPriceKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	// Пресеты по 3 в ряд
+	var row []tgbotapi.InlineKeyboardButton
+	for _, p := range pricePresets {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("до %d zł", p), fmt.Sprintf("price:%d", p)))
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	noLimit := tgbotapi.NewInlineKeyboardButtonData("♾ Без ограничения", "price:0")
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(noLimit))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Обработка выбора цены
+func (h *Handler) HandlePrice(cq *tgbotapi.CallbackQuery, priceStr string) {
+	chatID := cq.Message.Chat.ID
+
+	maxPrice, err := strconv.Atoi(priceStr)
+	if err != nil || maxPrice < 0 {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Неверная цена"))
+		return
+	}
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	sub.MaxPrice = maxPrice
+	err = h.saveCurrent(chatID, sub)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить цену."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Цена выбрана"))
+	h.SendSubscriptionSummary(chatID)
+}
+
+func formatMaxPrice(maxPrice int) string {
+	if maxPrice <= 0 {
+		return "без ограничения"
+	}
+	return fmt.Sprintf("до %d zł/ч", maxPrice)
+}
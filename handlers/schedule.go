@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"strings"
-	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -29,7 +28,17 @@ func (h *Handler) SendDaysSelection(chatID int64) {
 		return
 	}
 
-	msg := tgbotapi.NewMessage(chatID, "📅 Шаг 3/4: Выбери дни недели\n\nВ какие дни искать свободные корты?")
+	// /check с датой: день уже выбран
+	if h.checkMode[chatID] && len(sub.Dates) > 0 {
+		h.SendTimeSelection(chatID)
+		return
+	}
+
+	text := "📅 Шаг 6/9: Выбери дни недели\n\nВ какие дни искать свободные корты? Или выбери конкретные даты в календаре."
+	if len(sub.Dates) > 0 {
+		text += "\n\nСейчас выбраны даты: " + formatDates(sub.Dates)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = h.buildDaysKeyboard(sub.Days)
 	h.Bot.Send(msg)
 }
@@ -55,6 +64,8 @@ func (h *Handler) buildDaysKeyboard(selectedDays []string) tgbotapi.InlineKeyboa
 	weekdaysBtn := tgbotapi.NewInlineKeyboardButtonData("Будни (Пн-Пт)", "days_weekdays")
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(allWeekBtn, weekdaysBtn))
 
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🗓 Конкретные даты", "dates_pick")))
+
 	done := tgbotapi.NewInlineKeyboardButtonData("✅ Готово", "days_done")
 	rows = append(rows, tgbotapi.NewInlineKeyboardRow(done))
 
@@ -86,11 +97,7 @@ func (h *Handler) HandleDayToggle(cq *tgbotapi.CallbackQuery, day string) {
 	} else {
 		sub.Days = append(sub.Days, day)
 	}
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
+	err = h.saveCurrent(chatID, sub)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
@@ -113,11 +120,7 @@ func (h *Handler) HandleDaysAll(cq *tgbotapi.CallbackQuery) {
 	}
 
 	sub.Days = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
+	err = h.saveCurrent(chatID, sub)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
@@ -140,11 +143,7 @@ func (h *Handler) HandleDaysWeekdays(cq *tgbotapi.CallbackQuery) {
 	}
 
 	sub.Days = []string{"Mon", "Tue", "Wed", "Thu", "Fri"}
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
+	err = h.saveCurrent(chatID, sub)
 	if err != nil {
 		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
@@ -171,13 +170,52 @@ func (h *Handler) HandleDaysDone(cq *tgbotapi.CallbackQuery) {
 		return
 	}
 
+	// Дни недели заменяют ранее выбранные конкретные даты
+	if len(sub.Dates) > 0 {
+		sub.Dates = nil
+		if err := h.saveCurrent(chatID, sub); err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+			return
+		}
+	}
+
 	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Дни выбраны"))
-	h.SendTimeSelection(chatID)
+	h.continueToTime(chatID, sub)
 }
 
-// Шаг 4: Выбор времени - начало
+// Шаг 7: Выбор времени - начало
+// Если выбрано несколько дней, сначала спрашиваем: одно время на все дни или свое для каждого
 func (h *Handler) SendTimeSelection(chatID int64) {
-	msg := tgbotapi.NewMessage(chatID, "⏰ Шаг 4/4: Выбери время\n\nСначала выбери удобный вариант или настрой свое время:")
+	delete(dayTimeDrafts, chatID)
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil || sub == nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке подписки."))
+		return
+	}
+
+	current := ""
+	if sub.HasTimeWindows() {
+		current = "\n\nСейчас: " + formatTimeWindows(sub)
+	}
+
+	if len(sub.Days) < 2 {
+		h.sendTimePresets(chatID, "⏰ Шаг 7/9: Выбери время\n\nСначала выбери удобный вариант или настрой свое время:"+current)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, "⏰ Шаг 7/9: Выбери время\n\nВремя одинаковое для всех выбранных дней или свое для каждого?"+current)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🕐 Одинаковое для всех дней", "time_mode:same")),
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("📅 Настроить по дням", "time_mode:per_day")),
+	)
+	h.Bot.Send(msg)
+}
+
+// sendTimePresets показывает быстрые варианты времени и кнопку своего времени
+func (h *Handler) sendTimePresets(chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = h.buildTimePresetsKeyboard()
 	h.Bot.Send(msg)
 }
@@ -222,21 +260,7 @@ func (h *Handler) HandleTimePreset(cq *tgbotapi.CallbackQuery, timeRange string)
 		return
 	}
 
-	sub.TimeFrom = timeFrom
-	sub.TimeTo = timeTo
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
-	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
-		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
-		return
-	}
-
-	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Время выбрано"))
-	h.SendSubscriptionSummary(chatID)
+	h.saveTimeWindow(cq, sub, timeFrom, timeTo)
 }
 
 // Начало кастомного выбора времени
@@ -325,16 +349,17 @@ func (h *Handler) HandleTimeFrom(cq *tgbotapi.CallbackQuery, timeFrom string) {
 		return
 	}
 
-	sub.TimeFrom = timeFrom
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
+	// При настройке по дням начало окна держим в черновике: общего окна TimeFrom-TimeTo в этом режиме нет
+	if draft := dayTimeDrafts[chatID]; draft != nil {
+		draft.From = timeFrom
 	} else {
-		err = h.Store.Save(sub)
-	}
-	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
-		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
-		return
+		sub.TimeFrom = timeFrom
+		err = h.saveCurrent(chatID, sub)
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+			return
+		}
 	}
 
 	h.Bot.Request(tgbotapi.NewCallback(cq.ID, fmt.Sprintf("✅ Время начала: %s", timeFrom)))
@@ -371,26 +396,18 @@ func (h *Handler) HandleTimeTo(cq *tgbotapi.CallbackQuery, timeTo string) {
 		return
 	}
 
-	// Проверка, что время окончания больше времени начала
-	if timeTo <= sub.TimeFrom {
-		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Время окончания должно быть больше времени начала"))
-		return
+	timeFrom := sub.TimeFrom
+	if draft := dayTimeDrafts[chatID]; draft != nil {
+		timeFrom = draft.From
 	}
 
-	sub.TimeTo = timeTo
-	if h.checkMode[chatID] {
-		err = h.Store.SaveCheck(sub)
-	} else {
-		err = h.Store.Save(sub)
-	}
-	if err != nil {
-		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить время."))
-		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+	// Проверка, что время окончания больше времени начала
+	if timeTo <= timeFrom {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Время окончания должно быть больше времени начала"))
 		return
 	}
 
-	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Время выбрано"))
-	h.SendSubscriptionSummary(chatID)
+	h.saveTimeWindow(cq, sub, timeFrom, timeTo)
 }
 
 func (h *Handler) SendSubscriptionSummary(chatID int64) {
@@ -403,64 +420,46 @@ func (h *Handler) SendSubscriptionSummary(chatID int64) {
 		return
 	}
 
+	_, isFieldEdit := h.editField[chatID]
+
 	var text string
-	if isCheckMode {
+	switch {
+	case isCheckMode:
 		// Режим check - одноразовая проверка
-		text = fmt.Sprintf(
-			"🔍 Выполняю разовую проверку!\n\n"+
-				"🏙 Районы: %s\n"+
-				"🎾 Корты: %d выбрано\n"+
-				"📅 Дни: %s\n"+
-				"⏰ Время: %s - %s\n\n"+
-				"Ищу доступные слоты...",
-			strings.Join(sub.Districts, ", "),
-			len(sub.Courts),
-			formatDays(sub.Days),
-			sub.TimeFrom,
-			sub.TimeTo,
-		)
-	} else {
+		text = "🔍 Выполняю разовую проверку!\n\n" + formatSubscription(sub) + "\n\nИщу доступные слоты..."
+	case isFieldEdit:
+		// Изменение одного поля из /my_subs
+		text = fmt.Sprintf("✅ Подписка «%s» обновлена!\n\n", sub.DisplayName()) + formatSubscription(sub) + "\n\nПроверяю доступные слоты..."
+	default:
 		// Режим subscribe - постоянная подписка
-		text = fmt.Sprintf(
-			"✅ Подписка настроена!\n\n"+
-				"🏙 Районы: %s\n"+
-				"🎾 Корты: %d выбрано\n"+
-				"📅 Дни: %s\n"+
-				"⏰ Время: %s - %s\n\n"+
-				"Проверяю доступные слоты...",
-			strings.Join(sub.Districts, ", "),
-			len(sub.Courts),
-			formatDays(sub.Days),
-			sub.TimeFrom,
-			sub.TimeTo,
-		)
-	}
-
-	h.Bot.Send(tgbotapi.NewMessage(chatID, text))
-
-	// Запускаем проверку для обоих режимов
+		text = fmt.Sprintf("✅ Подписка «%s» настроена!\n\n", sub.DisplayName()) + formatSubscription(sub) + "\n\nПроверяю доступные слоты...\n\nВсе подписки: /my_subs"
+	}
+
+	reply := tgbotapi.NewMessage(chatID, text)
+	if !isCheckMode {
+		// Из итога можно сразу поправить другое поле
+		reply.ReplyMarkup = subscriptionKeyboard(sub)
+	}
+	h.Bot.Send(reply)
+
+	// Запускаем проверку для обоих режимов (подписка уже загружена - checker не читает ее из Redis)
 	if h.Checker != nil {
-		h.Checker.CheckSubscriptionNow(chatID)
+		h.Checker.CheckSubscriptionNow(sub)
 	}
 
-	// Если режим check, удаляем временную подписку после проверки
 	if isCheckMode {
-		go func() {
-			// CheckSubscriptionNow запускается в goroutine и читает из Redis (checker.go:156-164)
-			// Ждем пока checker прочитает подписку
-			time.Sleep(2 * time.Second)
-
-			if err := h.Store.DeleteCheck(chatID); err != nil {
-				log.Printf("⚠️ Ошибка при удалении временной подписки: %v", err)
-			} else {
-				log.Printf("🗑️ Временная подписка удалена для chatID: %d", chatID)
-			}
-
-			// Очищаем флаг режима
-			delete(h.checkMode, chatID)
-		}()
-	} else {
-		// Очищаем флаг режима для subscribe тоже
-		delete(h.checkMode, chatID)
+		// Режим check - удаляем временную подписку
+		if err := h.Store.DeleteCheck(chatID); err != nil {
+			log.Printf("⚠️ Ошибка при удалении временной подписки: %v", err)
+		} else {
+			log.Printf("🗑️ Временная подписка удалена для chatID: %d", chatID)
+		}
+	} else if err := h.Store.ClearEditing(chatID); err != nil {
+		// Режим subscribe - настройка подписки завершена
+		log.Printf("⚠️ Ошибка при завершении настройки подписки: %v", err)
 	}
+
+	// Очищаем флаги режима
+	delete(h.checkMode, chatID)
+	delete(h.editField, chatID)
 }
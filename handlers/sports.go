@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Шаг 1: Выбор вида спорта
+func (h *Handler) sendSportSelection(chatID int64) {
+	msg := tgbotapi.NewMessage(chatID, "🏅 Шаг 1/9: Выбери вид спорта\n\nДля какой дисциплины искать свободные корты?")
+	msg.ReplyMarkup = h.buildSportsKeyboard()
+	h.Bot.Send(msg)
+}
+
+func (h *Handler) buildSportsKeyboard() tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, sport := range types.Sports {
+		btn := tgbotapi.NewInlineKeyboardButtonData(sport.Name, "sport:"+sport.Code)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (h *Handler) HandleSportSelect(cq *tgbotapi.CallbackQuery, sport string) {
+	chatID := cq.Message.Chat.ID
+
+	known := false
+	for _, s := range types.Sports {
+		if s.Code == sport {
+			known = true
+			break
+		}
+	}
+	if !known {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Неизвестный вид спорта"))
+		return
+	}
+
+	sub, err := h.Store.GetCurrent(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при чтении подписки."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+	// Настройка подписки могла истечь (или /check начат заново) - начинаем с новой записи
+	isNew := sub == nil
+	if isNew {
+		sub = &storage.Subscription{ChatID: chatID}
+	}
+
+	// Клубы разных дисциплин не пересекаются - сбрасываем выбранные корты при смене спорта
+	if types.SportOrDefault(sub.Sport) != sport {
+		sub.Courts = nil
+	}
+	sub.Sport = sport
+
+	err = h.saveCurrent(chatID, sub)
+	if err == nil && isNew && !h.checkMode[chatID] {
+		err = h.Store.SetEditing(chatID, sub.ID)
+	}
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить выбор."))
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ "+types.SportName(sport)))
+	h.sendCitySelection(chatID)
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"court-bot/storage"
+	"court-bot/types"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxWatchesPerChat = 5  // активных слежений за отменами на чат
+	maxWatchCells     = 60 // забронированных ячеек на экране выбора (лимит клавиатуры Telegram)
+	watchDaysAhead    = 14 // на сколько дней вперед можно поставить слежение
+)
+
+// watchDraft - незавершенная настройка слежения за отменой (клуб -> дата -> ячейка)
+type watchDraft struct {
+	Sport    string
+	City     string
+	ClubID   string
+	ClubName string
+	Date     string
+	Cells    []types.Slot // забронированные ячейки выбранной даты (callback передает индекс)
+}
+
+// watchDrafts хранит настройку слежения по чатам (как courtsIndexCache - из-за лимита callback_data)
+var watchDrafts = make(map[int64]*watchDraft)
+
+// HandleWatch начинает настройку слежения: /watch [название клуба]
+// Без аргумента предлагает клубы из подписки
+func (h *Handler) HandleWatch(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	watches, _ := h.Store.GetWatches(chatID)
+	if len(watches) >= maxWatchesPerChat {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("⚠️ Можно следить не больше чем за %d слотами. Удали лишние в /watches.", maxWatchesPerChat)))
+		return
+	}
+
+	// Вид спорта берем из первой подписки с клубами, клубы - из всех подписок этого спорта
+	sport := types.SportTennis
+	subs, _ := h.Store.ListChat(chatID)
+	var withCourts []*storage.Subscription
+	for _, sub := range subs {
+		if len(sub.Courts) == 0 {
+			continue
+		}
+		if len(withCourts) == 0 {
+			sport = types.SportOrDefault(sub.Sport)
+		}
+		if types.SportOrDefault(sub.Sport) == sport {
+			withCourts = append(withCourts, sub)
+		}
+	}
+	watchDrafts[chatID] = &watchDraft{Sport: sport}
+
+	var clubs []types.Court
+	if query := strings.TrimSpace(msg.CommandArguments()); query != "" {
+		catalog, err := h.Store.ListCatalogCourts()
+		if err != nil {
+			h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке каталога клубов."))
+			return
+		}
+		clubs = findClubs(catalog, query)
+	} else {
+		seen := make(map[string]bool)
+		for _, sub := range withCourts {
+			for _, id := range sub.Courts {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				club := types.Court{ID: id, Name: id, City: sub.City}
+				if c, err := h.Store.GetCatalogCourt(id); err == nil && c != nil {
+					club = *c
+				}
+				clubs = append(clubs, club)
+			}
+		}
+	}
+
+	if len(clubs) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "🤷 Не нашел клубов.\n\nУкажи название: /watch Legia — или выбери клубы в /subscribe."))
+		return
+	}
+	if len(clubs) > maxClubMatches {
+		clubs = clubs[:maxClubMatches]
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, c := range clubs {
+		btn := tgbotapi.NewInlineKeyboardButtonData(c.Name, "watch_club:"+c.ID)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+	reply := tgbotapi.NewMessage(chatID, "👀 Слежение за отменой брони\n\nВыбери клуб:")
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.Bot.Send(reply)
+}
+
+// HandleWatchClub - выбран клуб, предлагаем дату
+func (h *Handler) HandleWatchClub(cq *tgbotapi.CallbackQuery, clubID string) {
+	chatID := cq.Message.Chat.ID
+	draft := watchDrafts[chatID]
+	if draft == nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Начни заново: /watch"))
+		return
+	}
+
+	draft.ClubID, draft.ClubName = clubID, clubID
+	if c, err := h.Store.GetCatalogCourt(clubID); err == nil && c != nil {
+		draft.ClubName, draft.City = c.Name, c.City
+	}
+
+	loc := types.CityLocation(draft.City)
+	now := time.Now().In(loc)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i := 0; i < watchDaysAhead; i++ {
+		day := now.AddDate(0, 0, i)
+		label := fmt.Sprintf("%s %s", dayNames[day.Weekday().String()[:3]], day.Format("02.01"))
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "watch_date:"+day.Format("2006-01-02")))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🎾 %s\n\n📅 Выбери дату:", draft.ClubName))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.Bot.Send(msg)
+}
+
+// HandleWatchDate - выбрана дата, показываем забронированные ячейки графика
+func (h *Handler) HandleWatchDate(cq *tgbotapi.CallbackQuery, date string) {
+	chatID := cq.Message.Chat.ID
+	draft := watchDrafts[chatID]
+	if draft == nil || draft.ClubID == "" {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Начни заново: /watch"))
+		return
+	}
+	draft.Date = date
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "🔄 Загружаю график..."))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	booked, err := h.Source.FetchBooked(ctx, draft.Sport, draft.ClubID, date)
+	if err != nil {
+		log.Printf("⚠️ Error fetching booked cells for %s on %s: %v", draft.ClubID, date, err)
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось загрузить график. Попробуй позже."))
+		return
+	}
+	if len(booked) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "🤷 На эту дату нет забронированных слотов — похоже, все свободно или клуб закрыт."))
+		return
+	}
+	if len(booked) > maxWatchCells {
+		booked = booked[:maxWatchCells]
+	}
+	draft.Cells = booked
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, slot := range booked {
+		label := fmt.Sprintf("🔒 %s–%s %s", slot.Time, slot.EndTime(), slot.CourtType)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "watch_cell:"+strconv.Itoa(i))))
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🎾 %s, %s\n\nВыбери занятый слот — я сообщу, если бронь отменят:", draft.ClubName, date))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.Bot.Send(msg)
+}
+
+// HandleWatchCell - выбрана ячейка, сохраняем слежение
+func (h *Handler) HandleWatchCell(cq *tgbotapi.CallbackQuery, index string) {
+	chatID := cq.Message.Chat.ID
+	draft := watchDrafts[chatID]
+	i, err := strconv.Atoi(index)
+	if draft == nil || err != nil || i < 0 || i >= len(draft.Cells) {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Начни заново: /watch"))
+		return
+	}
+	cell := draft.Cells[i]
+
+	w := storage.Watch{
+		ID:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		ChatID:    chatID,
+		Sport:     draft.Sport,
+		City:      draft.City,
+		ClubID:    draft.ClubID,
+		ClubName:  draft.ClubName,
+		Court:     cell.CourtType,
+		Date:      cell.Date,
+		Time:      cell.Time,
+		CreatedAt: time.Now(),
+	}
+	if err := h.Store.AddWatch(w, maxWatchesPerChat); err != nil {
+		if errors.Is(err, storage.ErrWatchLimit) {
+			h.Bot.Request(tgbotapi.NewCallback(cq.ID, "⚠️ Слишком много слежений"))
+			return
+		}
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Не удалось сохранить слежение."))
+		return
+	}
+	delete(watchDrafts, chatID)
+
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "✅ Слежу"))
+	h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("👀 Слежу за отменой: %s, %s — %s %s\n\nПроверяю этот корт каждые несколько минут. Все слежения: /watches", w.ClubName, w.Court, w.Date, w.Time)))
+}
+
+// HandleWatches показывает активные слежения с кнопками удаления
+func (h *Handler) HandleWatches(msg *tgbotapi.Message) {
+	chatID := msg.Chat.ID
+
+	watches, err := h.Store.GetWatches(chatID)
+	if err != nil {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "⚠️ Ошибка при загрузке слежений."))
+		return
+	}
+	if len(watches) == 0 {
+		h.Bot.Send(tgbotapi.NewMessage(chatID, "Слежений за отменами нет.\n\nИспользуй /watch чтобы выбрать занятый слот."))
+		return
+	}
+
+	var b strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	b.WriteString("👀 Слежения за отменами:\n\n")
+	for i, w := range watches {
+		fmt.Fprintf(&b, "%d. %s, %s — %s %s\n", i+1, w.ClubName, w.Court, w.Date, w.Time)
+		btn := tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🗑 Удалить %d", i+1), "watch_del:"+w.ID)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(btn))
+	}
+	reply := tgbotapi.NewMessage(chatID, b.String())
+	reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.Bot.Send(reply)
+}
+
+// HandleWatchDelete удаляет слежение
+func (h *Handler) HandleWatchDelete(cq *tgbotapi.CallbackQuery, id string) {
+	chatID := cq.Message.Chat.ID
+
+	remaining, err := h.Store.RemoveWatches(chatID, []string{id})
+	if err != nil {
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Ошибка"))
+		return
+	}
+	h.Bot.Request(tgbotapi.NewCallback(cq.ID, "🗑 Удалено"))
+	h.Bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑 Слежение удалено. Осталось: %d", remaining)))
+}
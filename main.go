@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"court-bot/checker"
@@ -17,6 +22,18 @@ import (
 
 var store *storage.Storage
 
+// adminChatID - чат администратора для служебных уведомлений (ADMIN_CHAT_ID), 0 = не задан
+var adminChatID, _ = strconv.ParseInt(os.Getenv("ADMIN_CHAT_ID"), 10, 64)
+
+// notifyAdmin отправляет служебное уведомление администратору
+func notifyAdmin(bot *tgbotapi.BotAPI, text string) {
+	if adminChatID == 0 {
+		log.Printf("⚠️ Admin alert (ADMIN_CHAT_ID not set): %s", text)
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(adminChatID, text))
+}
+
 func initStorage() {
 	addr := os.Getenv("REDIS_ADDR")
 	pass := os.Getenv("REDIS_PASSWORD")
@@ -29,8 +46,28 @@ func initStorage() {
 	}
 }
 
+// envInt читает целое число из переменной окружения (или возвращает значение по умолчанию)
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// envFloat читает дробное число из переменной окружения (или возвращает значение по умолчанию)
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
 func main() {
-	// Set timezone to Europe/Warsaw (CET/CEST)
+	// Контекст отменяется по SIGINT/SIGTERM - останавливаем проверки и прием обновлений
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Set timezone to Europe/Warsaw (CET/CEST) for logs; slot times use each city's own timezone
 	loc, err := time.LoadLocation("Europe/Warsaw")
 	if err != nil {
 		log.Printf("⚠️ Failed to load Warsaw timezone: %v (using UTC)", err)
@@ -53,10 +90,28 @@ func main() {
 	log.Printf("🤖 Authorized on account %s", bot.Self.UserName)
 
 	initStorage()
+	if n, err := store.MigrateLegacySubscriptions(); err != nil {
+		log.Printf("⚠️ Failed to migrate subscriptions: %v", err)
+	} else if n > 0 {
+		log.Printf("📦 Migrated %d subscriptions to the multi-subscription format", n)
+	}
+
+	// Источник слотов - скрапер kluby.org (с кешированием в Redis)
+	// KLUBY_RPS / KLUBY_BURST - общий лимит запросов к kluby.org для всех воркеров
+	// KLUBY_EMAIL / KLUBY_PASSWORD - учетные данные для автоматического входа
+	source := parser.NewKluby(store, parser.Config{
+		RPS:      envFloat("KLUBY_RPS", 2),
+		Burst:    envInt("KLUBY_BURST", 2),
+		Email:    os.Getenv("KLUBY_EMAIL"),
+		Password: os.Getenv("KLUBY_PASSWORD"),
+	})
+	source.OnLoginFailure(func(err error, failures int) {
+		notifyAdmin(bot, fmt.Sprintf("🔐 Не удается войти на kluby.org (%d попыток подряд): %v\n\nПроверь KLUBY_EMAIL / KLUBY_PASSWORD.", failures, err))
+	})
 
 	// Загружаем список районов из kluby.org (с кешированием в Redis)
 	log.Println("📍 Loading Warsaw districts...")
-	if err := handlers.InitDistricts(store); err != nil {
+	if err := handlers.InitDistricts(source); err != nil {
 		log.Printf("⚠️ Failed to load districts: %v (using fallback)", err)
 	}
 
@@ -69,20 +124,37 @@ func main() {
 			resp.Body.Close()
 		}
 		// Запускаем периодический пинг
-		parser.KeepCookiesAlive()
+		source.KeepCookiesAlive(ctx)
 	}()
 
 	// Запускаем сервис проверки доступности в отдельной горутине
-	checkerService := checker.New(bot, store)
-	go checkerService.Start()
+	// SCRAPE_WORKERS - количество параллельных загрузок графиков
+	checkerService := checker.New(bot, store, source, envInt("SCRAPE_WORKERS", 4))
+	// CHECK_SCHEDULE / CHECK_SCHEDULE_FILE - окна времени суток и интервалы опроса по удаленности даты
+	schedule, err := checker.LoadPollSchedule()
+	if err != nil {
+		log.Fatalf("❌ Invalid check schedule: %v", err)
+	}
+	checkerService.Schedule = schedule
+	checkerService.OnAlert(func(text string) {
+		notifyAdmin(bot, text)
+	})
+	go checkerService.Start(ctx)
 
 	// Инициализируем обработчики (передаем checker для немедленной проверки после подписки)
-	handler := handlers.New(bot, store, checkerService)
+	handler := handlers.New(bot, store, checkerService, source)
+	handler.AdminChatID = adminChatID
 
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 	updates := bot.GetUpdatesChan(u)
 
+	go func() {
+		<-ctx.Done()
+		log.Println("🛑 Shutting down...")
+		bot.StopReceivingUpdates()
+	}()
+
 	log.Println("✅ Bot is running...")
 
 	for update := range updates {
@@ -113,6 +185,30 @@ func handleMessage(bot *tgbotapi.BotAPI, h *handlers.Handler, msg *tgbotapi.Mess
 	case "get_current":
 		h.HandleGetCurrent(msg)
 
+	case "club":
+		h.HandleClub(msg)
+
+	case "notify_gone":
+		h.HandleNotifyGone(msg)
+
+	case "insights":
+		h.HandleInsights(msg)
+
+	case "watch":
+		h.HandleWatch(msg)
+
+	case "watches":
+		h.HandleWatches(msg)
+
+	case "pause":
+		h.HandlePause(msg)
+
+	case "resume":
+		h.HandleResume(msg)
+
+	case "schedule":
+		h.HandleSchedule(msg)
+
 	default:
 		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная команда. Попробуй /start"))
 	}
@@ -127,11 +223,24 @@ func handleCallback(h *handlers.Handler, cq *tgbotapi.CallbackQuery) {
 
 	// Роутинг callback'ов
 	switch {
+	// Выбор вида спорта
+	case strings.HasPrefix(data, "sport:"):
+		sport := strings.TrimPrefix(data, "sport:")
+		h.HandleSportSelect(cq, sport)
+
+	// Выбор города
+	case strings.HasPrefix(data, "city:"):
+		city := strings.TrimPrefix(data, "city:")
+		h.HandleCitySelect(cq, city)
+
 	// Выбор районов
 	case strings.HasPrefix(data, "toggle_district:"):
 		district := strings.TrimPrefix(data, "toggle_district:")
 		h.HandleDistrictToggle(cq, district)
 
+	case data == "districts_retry":
+		h.HandleDistrictsRetry(cq)
+
 	case data == "districts_done":
 		h.HandleDistrictsDone(cq)
 
@@ -143,6 +252,14 @@ func handleCallback(h *handlers.Handler, cq *tgbotapi.CallbackQuery) {
 	case data == "courts_done":
 		h.HandleCourtsDone(cq)
 
+	// Выбор типов кортов
+	case strings.HasPrefix(data, "toggle_env:"):
+		env := strings.TrimPrefix(data, "toggle_env:")
+		h.HandleEnvironmentToggle(cq, env)
+
+	case data == "envs_done":
+		h.HandleEnvironmentsDone(cq)
+
 	// Выбор дней
 	case strings.HasPrefix(data, "toggle_day:"):
 		day := strings.TrimPrefix(data, "toggle_day:")
@@ -157,11 +274,42 @@ func handleCallback(h *handlers.Handler, cq *tgbotapi.CallbackQuery) {
 	case data == "days_done":
 		h.HandleDaysDone(cq)
 
+	// Выбор конкретных дат в календаре
+	case data == "dates_pick":
+		h.HandleDatesPick(cq)
+
+	case strings.HasPrefix(data, "cal_nav:"):
+		h.HandleCalendar(cq, "nav", strings.TrimPrefix(data, "cal_nav:"))
+
+	case strings.HasPrefix(data, "cal_day:"):
+		h.HandleCalendar(cq, "day", strings.TrimPrefix(data, "cal_day:"))
+
+	case data == "cal_range":
+		h.HandleCalendar(cq, "range", "")
+
+	case data == "cal_clear":
+		h.HandleCalendar(cq, "clear", "")
+
+	case data == "cal_weekdays":
+		h.HandleCalendarWeekdays(cq)
+
+	case data == "cal_done":
+		h.HandleCalendarDone(cq)
+
+	case data == "cal_noop":
+		h.Bot.Request(tgbotapi.NewCallback(cq.ID, ""))
+
 	// Выбор времени - быстрые пресеты
 	case strings.HasPrefix(data, "time_preset:"):
 		timeRange := strings.TrimPrefix(data, "time_preset:")
 		h.HandleTimePreset(cq, timeRange)
 
+	// Выбор времени - одинаковое для всех дней или по дням
+	case strings.HasPrefix(data, "time_mode:"):
+		h.HandleTimeMode(cq, strings.TrimPrefix(data, "time_mode:"))
+	case strings.HasPrefix(data, "time_day:"):
+		h.HandleTimeDay(cq, strings.TrimPrefix(data, "time_day:"))
+
 	// Выбор времени - кастомный выбор
 	case data == "time_custom":
 		h.HandleTimeCustom(cq)
@@ -186,6 +334,47 @@ func handleCallback(h *handlers.Handler, cq *tgbotapi.CallbackQuery) {
 		offset := strings.TrimPrefix(data, "time_to_nav:")
 		h.HandleTimeToNav(cq, offset)
 
+	// Выбор минимальной длительности
+	case strings.HasPrefix(data, "min_duration:"):
+		minutes := strings.TrimPrefix(data, "min_duration:")
+		h.HandleMinDuration(cq, minutes)
+
+	// Выбор максимальной цены
+	case strings.HasPrefix(data, "price:"):
+		price := strings.TrimPrefix(data, "price:")
+		h.HandlePrice(cq, price)
+
+	// Слежение за отменами
+	case strings.HasPrefix(data, "watch_club:"):
+		h.HandleWatchClub(cq, strings.TrimPrefix(data, "watch_club:"))
+	case strings.HasPrefix(data, "watch_date:"):
+		h.HandleWatchDate(cq, strings.TrimPrefix(data, "watch_date:"))
+	case strings.HasPrefix(data, "watch_cell:"):
+		h.HandleWatchCell(cq, strings.TrimPrefix(data, "watch_cell:"))
+	case strings.HasPrefix(data, "watch_del:"):
+		h.HandleWatchDelete(cq, strings.TrimPrefix(data, "watch_del:"))
+
+	// Управление подписками из /my_subs
+	case strings.HasPrefix(data, "sub_field:"):
+		h.HandleSubscriptionField(cq, strings.TrimPrefix(data, "sub_field:"))
+	case strings.HasPrefix(data, "sub_pause:"):
+		h.HandleSubscriptionPause(cq, strings.TrimPrefix(data, "sub_pause:"))
+	case strings.HasPrefix(data, "sub_expire:"):
+		h.HandleSubscriptionExpiry(cq, strings.TrimPrefix(data, "sub_expire:"))
+	case strings.HasPrefix(data, "sub_edit:"):
+		h.HandleSubscriptionEdit(cq, strings.TrimPrefix(data, "sub_edit:"))
+	case strings.HasPrefix(data, "sub_del:"):
+		h.HandleSubscriptionDelete(cq, strings.TrimPrefix(data, "sub_del:"))
+
+	// Админская команда /schedule
+	case data == "schedule_run":
+		h.HandleScheduleRun(cq)
+
+	// Карточка клуба из результатов /club
+	case strings.HasPrefix(data, "club:"):
+		id := strings.TrimPrefix(data, "club:")
+		h.HandleClubSelect(cq, id)
+
 	default:
 		h.Bot.Request(tgbotapi.NewCallback(cq.ID, "Неизвестная команда"))
 	}
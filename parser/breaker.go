@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	breakerThreshold = 3                // неудачных запросов подряд, после которых клуб перестаем опрашивать
+	breakerCooldown  = 15 * time.Minute // пауза перед пробным запросом к клубу
+)
+
+// breakerState - состояние предохранителя одного клуба
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// breaker - предохранитель по клубам: после серии ошибок клуб не опрашивается breakerCooldown,
+// затем пропускается один пробный запрос (успех закрывает предохранитель, ошибка открывает снова)
+type breaker struct {
+	mu    sync.Mutex
+	clubs map[string]*breakerState
+}
+
+func newBreaker() *breaker {
+	return &breaker{clubs: make(map[string]*breakerState)}
+}
+
+// allow возвращает ErrCircuitOpen, если клуб временно не опрашивается
+func (b *breaker) allow(club string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.clubs[club]
+	if state == nil || state.failures < breakerThreshold {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(state.openUntil) {
+		return fmt.Errorf("%w: %s until %s", ErrCircuitOpen, club, state.openUntil.Format("15:04"))
+	}
+	// Пробный запрос: остальные ждут его результата еще один период
+	state.openUntil = now.Add(breakerCooldown)
+	return nil
+}
+
+// success закрывает предохранитель клуба
+func (b *breaker) success(club string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if state := b.clubs[club]; state != nil {
+		if state.failures >= breakerThreshold {
+			log.Printf("🔌 Circuit closed for %s", club)
+		}
+		delete(b.clubs, club)
+	}
+}
+
+// failure учитывает неудачный запрос и открывает предохранитель после breakerThreshold ошибок подряд
+func (b *breaker) failure(club string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.clubs[club]
+	if state == nil {
+		state = &breakerState{}
+		b.clubs[club] = state
+	}
+	state.failures++
+	if state.failures >= breakerThreshold {
+		state.openUntil = time.Now().Add(breakerCooldown)
+		log.Printf("🔌 Circuit open for %s for %s after %d failures: %v", club, breakerCooldown, state.failures, err)
+	}
+}
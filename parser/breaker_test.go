@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := newBreaker()
+	fail := errors.New("boom")
+
+	for i := 0; i < breakerThreshold; i++ {
+		if err := b.allow("klub"); err != nil {
+			t.Fatalf("allow before threshold: %v", err)
+		}
+		b.failure("klub", fail)
+	}
+	if err := b.allow("klub"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow after threshold = %v, want ErrCircuitOpen", err)
+	}
+	if err := b.allow("inny"); err != nil {
+		t.Fatalf("other club must not be affected: %v", err)
+	}
+
+	// После паузы пропускается один пробный запрос, успех закрывает предохранитель
+	b.clubs["klub"].openUntil = time.Now().Add(-time.Second)
+	if err := b.allow("klub"); err != nil {
+		t.Fatalf("probe request blocked: %v", err)
+	}
+	if err := b.allow("klub"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second request during probe = %v, want ErrCircuitOpen", err)
+	}
+	b.success("klub")
+	if err := b.allow("klub"); err != nil {
+		t.Fatalf("allow after success: %v", err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	if !retryable(&statusError{code: http.StatusServiceUnavailable}) || !retryable(&statusError{code: http.StatusTooManyRequests}) {
+		t.Error("5xx and 429 must be retried")
+	}
+	if retryable(&statusError{code: http.StatusNotFound}) {
+		t.Error("404 must not be retried")
+	}
+	if got := parseRetryAfter("7"); got != 7*time.Second {
+		t.Errorf("parseRetryAfter(7) = %s", got)
+	}
+	if got := retryDelay(&statusError{code: 429, retryAfter: time.Hour}, 1); got != retryMaxDelay {
+		t.Errorf("Retry-After must be capped, got %s", got)
+	}
+}
@@ -0,0 +1,18 @@
+package parser
+
+import "errors"
+
+// Ошибки разбора страниц kluby.org. Позволяют отличить "свободных кортов нет" от поломки скрапера
+// (проверяются через errors.Is, конкретная страница добавляется оберткой)
+var (
+	// ErrLoginRequired - страница доступна только после входа, а войти не удалось
+	ErrLoginRequired = errors.New("kluby.org login required")
+	// ErrLayoutChanged - на странице нет ожидаемой разметки (таблица графика, заголовки кортов)
+	ErrLayoutChanged = errors.New("kluby.org page layout changed")
+	// ErrEmptyGrid - таблица графика есть, но в ней нет ни одной строки со временем
+	ErrEmptyGrid = errors.New("kluby.org schedule grid is empty")
+	// ErrWrongDiscipline - по номеру dyscyplina открылся график другого вида спорта, а нужного на странице нет
+	ErrWrongDiscipline = errors.New("kluby.org schedule is for another discipline")
+	// ErrCircuitOpen - клуб временно не опрашивается после серии сетевых ошибок
+	ErrCircuitOpen = errors.New("kluby.org club is unreachable")
+)
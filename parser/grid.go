@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"court-bot/types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// cellState - состояние ячейки графика
+type cellState int
+
+const (
+	cellUnavailable cellState = iota // пустая ячейка или корт закрыт
+	cellFree                         // можно забронировать (ссылка "Rezerwuj")
+	cellBooked                       // забронировано ("Zarezerwowane")
+	cellBlocked                      // занято занятием, турниром и т.п. (текст без ссылки)
+)
+
+func (s cellState) String() string {
+	switch s {
+	case cellFree:
+		return "free"
+	case cellBooked:
+		return "booked"
+	case cellBlocked:
+		return "blocked"
+	default:
+		return "unavailable"
+	}
+}
+
+// gridCell - ячейка HTML таблицы вместе с областью, которую она занимает
+type gridCell struct {
+	State   cellState
+	Row     int // строка и столбец левого верхнего угла
+	Col     int
+	RowSpan int
+	ColSpan int
+	Text    string // видимый текст без лишних пробелов
+	Href    string // ссылка бронирования (только для cellFree)
+	Price   types.Money
+	Hourly  bool // Price указана за час, а не за всю ячейку
+}
+
+// Пределы rowspan/colspan как в браузерах (HTML спецификация): значения приходят со страницы,
+// и без ограничения одна ячейка с rowspan="100000000" развернулась бы в гигабайты памяти
+const (
+	maxRowSpan = 65534
+	maxColSpan = 1000
+	// maxGridColumns - ширина матрицы: в графике клуба не бывает столько кортов, дальше ячейки отбрасываются
+	maxGridColumns = 1000
+)
+
+// grid - таблица графика, развернутая в матрицу с учетом rowspan/colspan
+// Cells[r][c] указывает на ячейку, покрывающую позицию (nil если позиция пустая)
+type grid struct {
+	Cells [][]*gridCell
+	Width int
+}
+
+// buildGrid раскладывает строки таблицы (только строки с <td>) в матрицу за один проход по ячейкам
+// Занятость от rowspan хранится прямо в матрице, поэтому предыдущие строки не пересматриваются
+func buildGrid(table *goquery.Selection) *grid {
+	g := &grid{}
+
+	rows := table.Find("tr").FilterFunction(func(_ int, tr *goquery.Selection) bool {
+		return tr.Children().Filter("td").Length() > 0 // строки заголовка пропускаем
+	})
+
+	r := -1
+	rows.Each(func(_ int, tr *goquery.Selection) {
+
+		r++
+		g.ensureRow(r)
+
+		col := 0
+		tr.Children().Filter("td, th").Each(func(_ int, td *goquery.Selection) {
+			// Пропускаем позиции, занятые ячейками из предыдущих строк
+			for col < len(g.Cells[r]) && g.Cells[r][col] != nil {
+				col++
+			}
+
+			if col >= maxGridColumns {
+				return
+			}
+
+			cell := newGridCell(td)
+			cell.Row, cell.Col = r, col
+			// Как в браузерах, rowspan не выходит за последнюю строку таблицы
+			cell.RowSpan = min(cell.RowSpan, rows.Length()-r)
+			cell.ColSpan = min(cell.ColSpan, maxGridColumns-col)
+
+			for dr := 0; dr < cell.RowSpan; dr++ {
+				g.ensureRow(r + dr)
+				row := g.Cells[r+dr]
+				for len(row) < col+cell.ColSpan {
+					row = append(row, nil)
+				}
+				for dc := 0; dc < cell.ColSpan; dc++ {
+					row[col+dc] = cell
+				}
+				g.Cells[r+dr] = row
+			}
+
+			col += cell.ColSpan
+			if col > g.Width {
+				g.Width = col
+			}
+		})
+	})
+
+	return g
+}
+
+// ensureRow добавляет пустые строки, чтобы строка r существовала (rowspan может заходить вперед)
+func (g *grid) ensureRow(r int) {
+	for len(g.Cells) <= r {
+		g.Cells = append(g.Cells, nil)
+	}
+}
+
+// at возвращает ячейку, покрывающую позицию (r, c), или nil
+func (g *grid) at(r, c int) *gridCell {
+	if r < 0 || r >= len(g.Cells) || c < 0 || c >= len(g.Cells[r]) {
+		return nil
+	}
+	return g.Cells[r][c]
+}
+
+// newGridCell читает размеры ячейки и классифицирует ее содержимое
+func newGridCell(td *goquery.Selection) *gridCell {
+	cell := &gridCell{
+		RowSpan: spanAttr(td, "rowspan", maxRowSpan),
+		ColSpan: spanAttr(td, "colspan", maxColSpan),
+		Text:    strings.Join(strings.Fields(td.Text()), " "),
+	}
+
+	lower := strings.ToLower(cell.Text)
+	link := td.Find("a[href*='rezerwuj']").First()
+	href, hasHref := link.Attr("href")
+
+	switch {
+	case strings.Contains(lower, "zarezerwowane"):
+		cell.State = cellBooked
+	case hasHref && strings.Contains(strings.ToLower(link.Text()), "rezerwuj"):
+		cell.State = cellFree
+		cell.Href = href
+		cell.Price, cell.Hourly = extractPrice(td)
+	case cell.Text != "":
+		cell.State = cellBlocked
+	default:
+		cell.State = cellUnavailable
+	}
+	return cell
+}
+
+// spanAttr возвращает rowspan/colspan ячейки (от 1 до limit)
+func spanAttr(td *goquery.Selection, attr string, limit int) int {
+	v, ok := td.Attr(attr)
+	if !ok {
+		return 1
+	}
+	// При переполнении Atoi возвращает максимальное значение со знаком - оно тоже ограничивается
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if n < 1 || (err != nil && n != math.MaxInt) {
+		return 1
+	}
+	return min(n, limit)
+}
+
+// times возвращает время начала каждой строки по столбцу времени ("" если строка без времени)
+func (g *grid) times() []string {
+	times := make([]string, len(g.Cells))
+	for r := range g.Cells {
+		cell := g.at(r, 0)
+		if cell == nil || cell.Row != r || !strings.Contains(cell.Text, ":") {
+			continue
+		}
+		times[r] = normalizeTime(cell.Text)
+	}
+	return times
+}
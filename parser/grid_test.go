@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestBuildGridSpans(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "synthetic", "schedule--squash--squashcity--2025-11-07.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := buildGrid(doc.Find("table#grafik"))
+
+	// Строки: 16:00, 16:30, 17:00, 17:30, 18:00; столбцы: время + 3 корта
+	want := []string{
+		"blocked blocked free",
+		"blocked blocked free",
+		"free blocked blocked",
+		"booked free unavailable",
+		"booked free free",
+	}
+	if len(g.Cells) != len(want) {
+		t.Fatalf("rows = %d, want %d", len(g.Cells), len(want))
+	}
+	for r, w := range want {
+		states := make([]string, 0, 3)
+		for c := 1; c <= 3; c++ {
+			cell := g.at(r, c)
+			if cell == nil {
+				states = append(states, "nil")
+				continue
+			}
+			states = append(states, cell.State.String())
+		}
+		if got := strings.Join(states, " "); got != w {
+			t.Errorf("row %d = %q, want %q", r, got, w)
+		}
+	}
+
+	if times := g.times(); times[4] != "18:00" {
+		t.Errorf("times[4] = %q, want 18:00", times[4])
+	}
+	// Занятие с colspan=2 и rowspan=2 - одна ячейка на четыре позиции
+	if g.at(0, 1) != g.at(1, 2) {
+		t.Error("colspan/rowspan cell is not shared across covered positions")
+	}
+	if g.Width != 4 {
+		t.Errorf("width = %d, want 4", g.Width)
+	}
+}
+
+func TestBuildGridClampsSpans(t *testing.T) {
+	html := `<table id="grafik">
+		<tr><td>16:00</td><td rowspan="100000000">Zajęcia</td><td colspan="99999999999999999999">Turniej</td></tr>
+		<tr><td>16:30</td><td colspan="-3">Rezerwuj</td></tr>
+	</table>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := buildGrid(doc.Find("table#grafik"))
+
+	// rowspan заканчивается на последней строке таблицы, colspan ограничен шириной матрицы
+	if len(g.Cells) != 2 {
+		t.Fatalf("rows = %d, want 2", len(g.Cells))
+	}
+	if cell := g.at(0, 1); cell == nil || cell.RowSpan != 2 || g.at(1, 1) != cell {
+		t.Errorf("rowspan cell = %+v, want it to cover both rows", cell)
+	}
+	if g.Width != maxGridColumns {
+		t.Errorf("width = %d, want %d", g.Width, maxGridColumns)
+	}
+	if cell := g.at(1, 2); cell == nil || cell.ColSpan != 1 {
+		t.Errorf("negative colspan cell = %+v, want colspan 1", cell)
+	}
+}
+
+func TestDetectGranularity(t *testing.T) {
+	tests := []struct {
+		times []string
+		want  int
+	}{
+		{[]string{"07:00", "07:30", "08:00"}, 30},
+		{[]string{"07:00", "08:00", "09:00"}, 60},
+		{[]string{"07:00", "", "08:00", "08:15"}, 15}, // строки без времени пропускаются, берется меньший шаг
+		{[]string{"22:00", "23:00", "00:00"}, 60},     // переход через полночь не дает отрицательный шаг
+		{[]string{"07:00"}, 30},                       // одна строка - получасовая сетка по умолчанию
+		{nil, 30},
+	}
+	for _, tt := range tests {
+		if got := detectGranularity(tt.times); got != tt.want {
+			t.Errorf("detectGranularity(%v) = %d, want %d", tt.times, got, tt.want)
+		}
+	}
+}
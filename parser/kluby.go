@@ -1,16 +1,14 @@
 package parser
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"net/http"
-	"net/http/cookiejar"
-	"net/url"
-	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"court-bot/types"
@@ -19,75 +17,113 @@ import (
 )
 
 const (
-	baseURL    = "https://kluby.org"
-	userAgent  = "Mozilla/5.0 (compatible; CourtsBot/1.0)"
-	loginEmail = "wazap_by@mail.ru"
-	loginPass  = "6282373"
+	baseURL   = "https://kluby.org"
+	userAgent = "Mozilla/5.0 (compatible; CourtsBot/1.0)"
 )
 
-var (
-	lastRequest         time.Time
-	authenticatedClient *http.Client
-)
+// disciplines - значения параметра dyscyplina в графике kluby.org по умолчанию
+// Проверен только теннис (1, из исходной версии бота); остальные номера - предположение.
+// Страница графика содержит переключатель дисциплин, поэтому номера уточняются при загрузке
+// (см. ParseDisciplines и Kluby.learnDisciplines) и по умолчанию используются только до первой страницы
+var disciplines = map[string]int{
+	types.SportTennis:     1,
+	types.SportSquash:     2,
+	types.SportBadminton:  3,
+	types.SportPadel:      4,
+	types.SportPickleball: 5,
+}
 
-// AuthClient создает HTTP клиент с авторизацией
-// initAuthClient создает HTTP клиент с cookies для авторизации
-func initAuthClient() (*http.Client, error) {
-	// Используем кешированный клиент если есть
-	if authenticatedClient != nil {
-		return authenticatedClient, nil
+// disciplineID возвращает значение dyscyplina по умолчанию для вида спорта (неизвестный - теннис)
+func disciplineID(sport string) int {
+	if id, ok := disciplines[types.SportOrDefault(sport)]; ok {
+		return id
 	}
+	return disciplines[types.SportTennis]
+}
 
-	log.Println("🔐 Initializing authenticated client with cookies...")
+// Config - настройки источника kluby.org
+type Config struct {
+	RPS      float64 // общий лимит запросов в секунду для всех горутин
+	Burst    int     // допустимый всплеск запросов
+	Email    string  // учетные данные для автоматического входа (KLUBY_EMAIL)
+	Password string  // (KLUBY_PASSWORD)
+}
 
-	// Создаем клиент с cookie jar
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, err
-	}
+// Kluby - источник слотов на основе скрапинга kluby.org
+type Kluby struct {
+	store   Storage
+	limiter *tokenBucket
+	session *session
+	breaker *breaker
+
+	catalogMu      sync.Mutex
+	catalogUpdated map[string]time.Time // когда клуб последний раз обновлялся в каталоге (по графику)
+
+	disciplinesMu sync.Mutex
+	learned       map[string]int // номера dyscyplina, подтвержденные переключателем на странице графика
+}
+
+// catalogRefreshInterval - как часто обновлять каталог клуба по заголовкам графика
+const catalogRefreshInterval = 24 * time.Hour
 
-	client := &http.Client{
-		Jar:     jar,
-		Timeout: 15 * time.Second,
+// NewKluby создает источник kluby.org. store используется для кеширования районов, кортов и cookies сессии (может быть nil)
+func NewKluby(store Storage, cfg Config) *Kluby {
+	limiter := newTokenBucket(cfg.RPS, cfg.Burst)
+	return &Kluby{
+		store:          store,
+		limiter:        limiter,
+		session:        newSession(store, limiter, cfg.Email, cfg.Password),
+		breaker:        newBreaker(),
+		catalogUpdated: make(map[string]time.Time),
+		learned:        make(map[string]int),
 	}
+}
 
-	// Устанавливаем cookies для авторизации
-	u, _ := url.Parse("https://kluby.org")
-	cookies := []*http.Cookie{
-		{
-			Name:   "kluby_org",
-			Value:  os.Getenv("KLUBY_ORG"),
-			Domain: ".kluby.org",
-			Path:   "/",
-		},
-		{
-			Name:   "kluby_autolog",
-			Value:  os.Getenv("KLUBY_AUTOLOG"),
-			Domain: ".kluby.org",
-			Path:   "/",
-		},
-		{
-			Name:   "kluby_remember",
-			Value:  "1",
-			Domain: ".kluby.org",
-			Path:   "/",
-		},
+// disciplineFor возвращает номер dyscyplina для вида спорта: подтвержденный страницей или по умолчанию
+func (k *Kluby) disciplineFor(sport string) int {
+	k.disciplinesMu.Lock()
+	defer k.disciplinesMu.Unlock()
+	if id, ok := k.learned[types.SportOrDefault(sport)]; ok {
+		return id
 	}
-	jar.SetCookies(u, cookies)
+	return disciplineID(sport)
+}
 
-	log.Println("✅ Using authenticated client with cookies")
+// learnDisciplines запоминает номера дисциплин из переключателя на странице графика
+func (k *Kluby) learnDisciplines(found map[string]int) {
+	k.disciplinesMu.Lock()
+	defer k.disciplinesMu.Unlock()
+	for sport, id := range found {
+		if prev, ok := k.learned[sport]; ok && prev == id {
+			continue
+		}
+		if id != disciplineID(sport) {
+			log.Printf("🔀 kluby.org uses dyscyplina=%d for %s (default %d)", id, sport, disciplineID(sport))
+		}
+		k.learned[sport] = id
+	}
+}
 
-	authenticatedClient = client
-	return client, nil
+// OnLoginFailure задает обработчик повторяющихся ошибок входа (например, уведомление администратора)
+func (k *Kluby) OnLoginFailure(fn func(err error, failures int)) {
+	k.session.mu.Lock()
+	defer k.session.mu.Unlock()
+	k.session.onFailure = fn
 }
 
 // KeepCookiesAlive делает периодический пинг для поддержания активности куков
-func KeepCookiesAlive() {
+func (k *Kluby) KeepCookiesAlive(ctx context.Context) {
 	ticker := time.NewTicker(10 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		client, err := initAuthClient()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		client, _, err := k.session.get(ctx)
 		if err != nil {
 			log.Printf("⚠️ Cookie ping failed: error initializing client: %v", err)
 			continue
@@ -101,6 +137,9 @@ func KeepCookiesAlive() {
 		}
 		resp.Body.Close()
 
+		// Сохраняем обновленные cookies, чтобы сессия пережила перезапуск
+		k.session.save()
+
 		log.Printf("✅ Cookie ping successful (status: %d)", resp.StatusCode)
 	}
 }
@@ -129,6 +168,99 @@ func cleanCourtName(name string) string {
 	return name
 }
 
+// classifyEnvironment определяет тип корта по заголовку столбца (до очистки названия)
+// "Kort 3 ziemny otwart Korty odkryte" -> outdoor, "Kort 1 Balon" -> bubble, "Hala 1 Hala tenis" -> indoor
+func classifyEnvironment(header string) string {
+	h := strings.ToLower(header)
+
+	// Открытые проверяем первыми: "odkryte" содержит "kryt"
+	if strings.Contains(h, "otwart") ||
+		strings.Contains(h, "odkryt") ||
+		strings.Contains(h, "zewn") {
+		return types.EnvOutdoor
+	}
+
+	if strings.Contains(h, "balon") ||
+		strings.Contains(h, "pneumat") ||
+		strings.Contains(h, "namiot") {
+		return types.EnvBubble
+	}
+
+	// Хала, "zadaszony" (постоянная крыша) и все остальное считаем крытыми кортами
+	return types.EnvIndoor
+}
+
+// classifySurface определяет покрытие по заголовку столбца: "Kort 3 ziemny otwart" -> clay
+// Возвращает пустую строку если покрытие не указано
+func classifySurface(header string) string {
+	h := strings.ToLower(header)
+	switch {
+	case strings.Contains(h, "sztuczn"):
+		return types.SurfaceArtificial
+	case strings.Contains(h, "ziemn") || strings.Contains(h, "mączk") || strings.Contains(h, "maczk") || strings.Contains(h, "clay"):
+		return types.SurfaceClay
+	case strings.Contains(h, "traw") || strings.Contains(h, "grass"):
+		return types.SurfaceGrass
+	case strings.Contains(h, "dywan") || strings.Contains(h, "wykładzin") || strings.Contains(h, "carpet"):
+		return types.SurfaceCarpet
+	case strings.Contains(h, "hard") || strings.Contains(h, "akryl") || strings.Contains(h, "beton"):
+		return types.SurfaceHard
+	}
+	return ""
+}
+
+// extractPrice ищет цену в ячейке графика: в тексте и в подсказках (title, data-original-title и т.п.)
+// hourly - цена указана за час ("80 zł/h"); иначе это цена всей ячейки ("120 zł" за 2 часа падела)
+// Возвращает нулевую цену если kluby.org ее не показывает
+func extractPrice(td *goquery.Selection) (price types.Money, hourly bool) {
+	candidates := []string{td.Text()}
+	sources := []*goquery.Selection{td, td.Find("a[href*='rezerwuj']"), td.Find("[title], [data-original-title], [data-title], [data-content]")}
+	for _, sel := range sources {
+		for _, attr := range []string{"title", "data-original-title", "data-title", "data-content"} {
+			if v, ok := sel.Attr(attr); ok {
+				candidates = append(candidates, v)
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		if price, ok := types.ParseMoney(c); ok {
+			return price, types.IsHourly(c)
+		}
+	}
+	return types.Money{}, false
+}
+
+// minutesOf переводит "HH:MM" в минуты от полуночи
+func minutesOf(t string) (int, bool) {
+	var h, m int
+	if _, err := fmt.Sscanf(t, "%d:%d", &h, &m); err != nil {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// detectGranularity определяет шаг графика в минутах по времени строк (обычно 30 или 60)
+func detectGranularity(times []string) int {
+	step := 0
+	prev := -1
+	for _, t := range times {
+		m, ok := minutesOf(t)
+		if !ok {
+			continue
+		}
+		if prev >= 0 && m > prev && (step == 0 || m-prev < step) {
+			step = m - prev
+		}
+		prev = m
+	}
+
+	if step == 0 {
+		return 30 // kluby.org по умолчанию использует получасовую сетку
+	}
+	return step
+}
+
 // normalizeTime преобразует время к формату "HH:MM" (добавляет ведущий 0 если нужно)
 func normalizeTime(t string) string {
 	parts := strings.Split(t, ":")
@@ -152,31 +284,31 @@ func normalizeTime(t string) string {
 	return hour + ":" + minute
 }
 
-// rateLimit добавляет задержку между запросами
-func rateLimit() {
-	delay := time.Duration(200+rand.Intn(301)) * time.Millisecond
-	elapsed := time.Since(lastRequest)
-
-	if elapsed < delay {
-		time.Sleep(delay - elapsed)
-	}
-	lastRequest = time.Now()
+// rateLimit ждет свободный токен общего ограничителя запросов
+func (k *Kluby) rateLimit(ctx context.Context) error {
+	return k.limiter.Wait(ctx)
 }
 
 // Storage interface для избежания циклической зависимости
 type Storage interface {
-	GetDistricts() ([]string, error)
-	SaveDistricts(districts []string) error
-	GetCourts(districts []string) ([]byte, error)
-	SaveCourts(districts []string, courts interface{}) error
+	GetDistricts(city, sport string) ([]string, error)
+	SaveDistricts(city, sport string, districts []string) error
+	GetCourts(city, sport string, districts []string) ([]byte, error)
+	SaveCourts(city, sport string, districts []string, courts interface{}) error
+	GetSession() ([]byte, error)
+	SaveSession(data []byte) error
+	UpsertCatalogCourt(court types.Court) error
 }
 
-// FetchWarsawDistricts загружает список районов Варшавы из kluby.org
+// ListDistricts загружает список районов города для вида спорта из kluby.org
 // Использует Redis кеш если доступен
-func FetchWarsawDistricts(store Storage) ([]string, error) {
+func (k *Kluby) ListDistricts(ctx context.Context, city, sport string) ([]string, error) {
+	city = types.CityOrDefault(city)
+	sport = types.SportOrDefault(sport)
+
 	// Проверяем кеш
-	if store != nil {
-		cached, err := store.GetDistricts()
+	if k.store != nil {
+		cached, err := k.store.GetDistricts(city, sport)
 		if err == nil && cached != nil {
 			log.Printf("📍 Loaded %d districts from cache", len(cached))
 			return cached, nil
@@ -184,58 +316,22 @@ func FetchWarsawDistricts(store Storage) ([]string, error) {
 	}
 
 	// Кеша нет, парсим сайт
-	log.Println("🌐 Fetching districts from kluby.org...")
-	rateLimit()
-
-	// Инициализируем авторизованный клиент
-	client, err := initAuthClient()
-	if err != nil {
-		return nil, err
-	}
-
-	districtsURL := baseURL + "/tenis/kluby/warszawa"
-	req, err := http.NewRequest("GET", districtsURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", userAgent)
-
-	resp, err := client.Do(req)
+	log.Printf("🌐 Fetching %s districts in %s from kluby.org...", sport, city)
+	body, err := k.FetchPage(ctx, DistrictsURL(city, sport))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	districts, err := ParseDistricts(body, city, sport)
 	if err != nil {
 		return nil, err
 	}
 
-	districts := make([]string, 0)
-	seen := make(map[string]bool)
-
-	// Ищем ссылки на районы в "Lista dzielnic"
-	doc.Find("a[href*='/tenis/kluby/warszawa/']").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Извлекаем название района из текста ссылки
-		district := strings.TrimSpace(s.Text())
-
-		// Проверяем что это действительно район (не пустое и имеет правильный формат URL)
-		if district != "" && district != "Warszawa" && strings.Contains(href, "/tenis/kluby/warszawa/") && !seen[district] {
-			districts = append(districts, district)
-			seen[district] = true
-		}
-	})
-
-	log.Printf("📍 Found %d districts in Warsaw", len(districts))
+	log.Printf("📍 Found %d %s districts in %s", len(districts), sport, city)
 
 	// Сохраняем в кеш
-	if store != nil {
-		if err := store.SaveDistricts(districts); err != nil {
+	if k.store != nil {
+		if err := k.store.SaveDistricts(city, sport, districts); err != nil {
 			log.Printf("⚠️ Failed to cache districts: %v", err)
 		}
 	}
@@ -243,12 +339,15 @@ func FetchWarsawDistricts(store Storage) ([]string, error) {
 	return districts, nil
 }
 
-// FetchCourts загружает список кортов из kluby.org для выбранных районов
+// ListCourts загружает список кортов для вида спорта из kluby.org для выбранных районов города
 // Использует Redis кеш если доступен
-func FetchCourts(districts []string, store Storage) ([]types.Court, error) {
+func (k *Kluby) ListCourts(ctx context.Context, city, sport string, districts []string) ([]types.Court, error) {
+	city = types.CityOrDefault(city)
+	sport = types.SportOrDefault(sport)
+
 	// Проверяем кеш
-	if store != nil {
-		cached, err := store.GetCourts(districts)
+	if k.store != nil {
+		cached, err := k.store.GetCourts(city, sport, districts)
 		if err == nil && cached != nil {
 			var courts []types.Court
 			if json.Unmarshal(cached, &courts) == nil {
@@ -266,7 +365,7 @@ func FetchCourts(districts []string, store Storage) ([]types.Court, error) {
 	for _, district := range districts {
 		log.Printf("🔍 Fetching courts for district: %s", district)
 
-		courts, err := fetchCourtsForDistrict(district)
+		courts, err := k.fetchCourtsForDistrict(ctx, city, sport, district)
 		if err != nil {
 			log.Printf("⚠️ Error fetching courts for %s: %v", district, err)
 			continue
@@ -283,9 +382,18 @@ func FetchCourts(districts []string, store Storage) ([]types.Court, error) {
 
 	log.Printf("✅ Total courts found: %d", len(allCourts))
 
+	// Дополняем каталог клубов адресами и расстояниями
+	if k.store != nil {
+		for _, court := range allCourts {
+			if err := k.store.UpsertCatalogCourt(court); err != nil {
+				log.Printf("⚠️ Failed to update catalog for %s: %v", court.ID, err)
+			}
+		}
+	}
+
 	// Сохраняем в кеш
-	if store != nil {
-		if err := store.SaveCourts(districts, allCourts); err != nil {
+	if k.store != nil {
+		if err := k.store.SaveCourts(city, sport, districts, allCourts); err != nil {
 			log.Printf("⚠️ Failed to cache courts: %v", err)
 		}
 	}
@@ -311,456 +419,279 @@ func districtToSlug(district string) string {
 	return slug
 }
 
-// fetchCourtsForDistrict загружает корты для конкретного района
-func fetchCourtsForDistrict(district string) ([]types.Court, error) {
-	rateLimit()
-
-	// Инициализируем авторизованный клиент
-	client, err := initAuthClient()
-	if err != nil {
-		return nil, err
+// parseDistance извлекает расстояние из адреса: "ul. Kortowa 1 (3,2 km)" -> 3.2
+func parseDistance(address string) (float64, bool) {
+	end := strings.Index(address, " km)")
+	if end == -1 {
+		return 0, false
 	}
+	start := strings.LastIndex(address[:end], "(")
+	if start == -1 {
+		return 0, false
+	}
+	distStr := strings.TrimSpace(address[start+1 : end])
+	distStr = strings.ReplaceAll(distStr, ",", ".")
+	var dist float64
+	if _, err := fmt.Sscanf(distStr, "%f", &dist); err != nil {
+		return 0, false
+	}
+	return dist, true
+}
 
-	// URL страницы района: /tenis/kluby/warszawa/[slug]
-	districtSlug := districtToSlug(district)
-	districtURL := fmt.Sprintf("%s/tenis/kluby/warszawa/%s", baseURL, districtSlug)
-
-	req, err := http.NewRequest("GET", districtURL, nil)
-	if err != nil {
-		return nil, err
+// stripDistance убирает суффикс "(N km)" из адреса
+func stripDistance(address string) string {
+	if end := strings.Index(address, " km)"); end != -1 {
+		if start := strings.LastIndex(address[:end], "("); start != -1 {
+			address = address[:start] + address[end+len(" km)"):]
+		}
 	}
-	req.Header.Set("User-Agent", userAgent)
+	return strings.Join(strings.Fields(address), " ")
+}
 
-	resp, err := client.Do(req)
+// fetchCourtsForDistrict загружает корты для конкретного района
+func (k *Kluby) fetchCourtsForDistrict(ctx context.Context, city, sport, district string) ([]types.Court, error) {
+	body, err := k.FetchPage(ctx, ClubListURL(city, sport, district))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	courts, err := ParseClubList(body, city, sport, district)
 	if err != nil {
 		return nil, err
 	}
 
-	courts := make([]types.Court, 0)
-	seen := make(map[string]bool)
-
-	// Парсим карточки кортов
-	// Структура: <a href="/court-name"><img/><h4>Name</h4><p>Address</p></a>
-	// Категории спорта: <a href="/sport/..."><img/><h3>SPORT_NAME</h3></a>
-	doc.Find("a").Each(func(i int, s *goquery.Selection) {
-		href, exists := s.Attr("href")
-		if !exists {
-			return
-		}
-
-		// Пропускаем внешние ссылки и служебные страницы
-		if strings.HasPrefix(href, "http") ||
-			strings.HasPrefix(href, "//") ||
-			strings.Contains(href, "regulamin") ||
-			strings.Contains(href, "static") ||
-			href == "/" || href == "" {
-			return
-		}
-
-		// Пропускаем ссылки на спортивные категории (они имеют формат /sport/kluby/...)
-		if strings.Contains(href, "/tenis/") ||
-			strings.Contains(href, "/padel/") ||
-			strings.Contains(href, "/squash/") ||
-			strings.Contains(href, "/badminton/") ||
-			strings.Contains(href, "/pickleball/") ||
-			strings.Contains(href, "/golf/") ||
-			strings.Contains(href, "/bilard/") {
-			return
-		}
-
-		// Пропускаем навигационные ссылки
-		if strings.Contains(href, "/kluby/") {
-			return
-		}
+	now := time.Now()
+	for i := range courts {
+		courts[i].UpdatedAt = now
+	}
 
-		// Пропускаем события, турниры и другие пути (они содержат "/" в href после первого символа)
-		// Корты имеют простой формат: /court-slug (одно слово или слова через дефис)
-		// События/турниры: /zapisy/123, /turnieje/456
-		trimmedHref := strings.TrimPrefix(href, "/")
-		if strings.Contains(trimmedHref, "/") {
-			return
-		}
+	log.Printf("  → Found %d courts in %s", len(courts), district)
+	return courts, nil
+}
 
-		// Категории спорта используют h3, корты используют h4
-		// Пропускаем все ссылки с h3 (это категории спорта)
-		if s.Find("h3").Length() > 0 {
-			return
+// updateCatalog сохраняет в каталог сведения о клубе из заголовков графика (не чаще раза в сутки на клуб)
+// headers - заголовки столбцов кортов (без столбца времени)
+func (k *Kluby) updateCatalog(courtID, clubName, sport string, headers []string) {
+	if k.store == nil || len(headers) == 0 {
+		return
+	}
+
+	key := sport + ":" + courtID
+	k.catalogMu.Lock()
+	if last, ok := k.catalogUpdated[key]; ok && time.Since(last) < catalogRefreshInterval {
+		k.catalogMu.Unlock()
+		return
+	}
+	k.catalogUpdated[key] = time.Now()
+	k.catalogMu.Unlock()
+
+	court := types.Court{
+		ID:          courtID,
+		Name:        clubName,
+		URL:         baseURL + "/" + courtID,
+		Sports:      []string{sport},
+		CourtCounts: map[string]int{sport: len(headers)},
+		UpdatedAt:   time.Now(),
+	}
+	seenSurfaces := make(map[string]bool)
+	for _, header := range headers {
+		if classifyEnvironment(header) == types.EnvOutdoor {
+			court.Outdoor = true
+		} else {
+			court.Indoor = true
 		}
-
-		// Проверяем что внутри есть h4 (название корта)
-		heading := s.Find("h4").First()
-		if heading.Length() == 0 {
-			return
+		if surface := classifySurface(header); surface != "" && !seenSurfaces[surface] {
+			court.Surfaces = append(court.Surfaces, surface)
+			seenSurfaces[surface] = true
 		}
+	}
 
-		// Извлекаем название корта
-		name := strings.TrimSpace(heading.Text())
-		if name == "" || len(name) < 3 {
-			return
-		}
+	if err := k.store.UpsertCatalogCourt(court); err != nil {
+		log.Printf("⚠️ Failed to update catalog for %s: %v", courtID, err)
+	}
+}
 
-		// Пропускаем подозрительно короткие названия (обычно тестовые/неактивные корты)
-		// Реальные корты имеют нормальные названия типа "Park Tennis Academy", "OSIR Bemowo"
-		if len(name) <= 4 {
-			// Исключения: известные короткие названия могут быть добавлены сюда если нужно
-			return
-		}
+// fetchSchedulePage загружает страницу графика с учетом предохранителя клуба
+func (k *Kluby) fetchSchedulePage(ctx context.Context, courtID, scheduleURL string) ([]byte, error) {
+	// Клуб недавно не отвечал - не опрашиваем его до конца паузы
+	if err := k.breaker.allow(courtID); err != nil {
+		return nil, err
+	}
 
-		// Пропускаем названия с датами в скобках - это события (например "(2026-03-01)")
-		if strings.Contains(name, "(202") {
-			return
+	log.Printf("  → Fetching schedule page: %s", scheduleURL)
+	body, err := k.FetchPage(ctx, scheduleURL)
+	if err != nil {
+		if ctx.Err() == nil {
+			k.breaker.failure(courtID, err)
 		}
+		return nil, err
+	}
+	k.breaker.success(courtID)
 
-		// Проверяем возможно ли резервация
-		reservation := s.Find("span").First()
-		if reservation.Text() != "REZERWUJ" {
-			return
-		}
+	// Перелогин не помог - график недоступен без авторизации
+	if isLoggedOut(body) {
+		return nil, fmt.Errorf("%w: %s", ErrLoginRequired, courtID)
+	}
+	return body, nil
+}
 
-		// Извлекаем адрес (если есть)
-		address := ""
-		addressPara := s.Find("p").First()
-		if addressPara.Length() > 0 {
-			address = strings.TrimSpace(addressPara.Text())
+// disciplineOwner возвращает вид спорта, которому на странице принадлежит номер id ("" если никому)
+func disciplineOwner(found map[string]int, id int) string {
+	for sport, n := range found {
+		if n == id {
+			return sport
 		}
+	}
+	return ""
+}
 
-		// Фильтруем по расстоянию - если больше 50 км, вероятно ошибка
-		if strings.Contains(address, " km)") {
-			// Ищем число перед " km)"
-			parts := strings.Split(address, " km)")
-			if len(parts) > 0 {
-				distPart := parts[0]
-				lastSpace := strings.LastIndex(distPart, "(")
-				if lastSpace != -1 && lastSpace < len(distPart)-1 {
-					distStr := strings.TrimSpace(distPart[lastSpace+1:])
-					distStr = strings.ReplaceAll(distStr, ",", ".")
-					var dist float64
-					if _, err := fmt.Sscanf(distStr, "%f", &dist); err == nil {
-						if dist > 50.0 {
-							return // Пропускаем корты дальше 50 км (например asd2 с 6129 км)
-						}
-					}
-				}
+// fetchPage загружает страницу авторизованным клиентом с учетом общего лимита запросов
+// 5xx, 429 и таймауты повторяются с экспоненциальной паузой (Retry-After учитывается)
+// Возвращает тело и поколение сессии, которым была загружена страница
+func (k *Kluby) fetchPage(ctx context.Context, pageURL string) ([]byte, int, error) {
+	var lastErr error
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay(lastErr, attempt)
+			log.Printf("  🔁 Retry %d/%d for %s in %s: %v", attempt, retryAttempts-1, pageURL, delay.Round(time.Millisecond), lastErr)
+			if err := sleepCtx(ctx, delay); err != nil {
+				return nil, 0, err
 			}
 		}
 
-		// Очищаем href от query параметров
-		courtID := strings.Split(href, "?")[0]
-		courtID = strings.TrimPrefix(courtID, "/")
-
-		// Проверяем дубликаты
-		if courtID == "" || seen[courtID] {
-			return
+		body, gen, err := k.fetchOnce(ctx, pageURL)
+		if err == nil {
+			return body, gen, nil
 		}
-
-		seen[courtID] = true
-		court := types.Court{
-			ID:       courtID,
-			Name:     name,
-			District: district,
+		lastErr = err
+		if ctx.Err() != nil || !retryable(err) {
+			break
 		}
-
-		courts = append(courts, court)
-	})
-
-	log.Printf("  → Found %d courts in %s", len(courts), district)
-	return courts, nil
+	}
+	return nil, 0, lastErr
 }
 
-// CheckCourtSchedule проверяет график конкретного корта на заданную дату
-// courtID - ID корта (например "umacieja")
-// date - дата в формате "2025-11-05"
-// timeFrom, timeTo - диапазон времени (например "08:00", "22:00")
-func CheckCourtSchedule(courtID, date, timeFrom, timeTo string) ([]types.Slot, error) {
-	rateLimit()
+// fetchOnce выполняет один запрос страницы
+func (k *Kluby) fetchOnce(ctx context.Context, pageURL string) ([]byte, int, error) {
+	if err := k.rateLimit(ctx); err != nil {
+		return nil, 0, err
+	}
 
-	// Инициализируем авторизованный клиент
-	client, err := initAuthClient()
+	client, gen, err := k.session.get(ctx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Пробуем сначала страницу резервации (может не требовать логина)
-	reserveURL := fmt.Sprintf("%s/%s/rezerwacje?data_grafiku=%s&dyscyplina=1", baseURL, courtID, date)
-	log.Printf("  → Trying reservations page: %s", reserveURL)
-
-	req, err := http.NewRequest("GET", reserveURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req.Header.Set("User-Agent", userAgent)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	resp.Body.Close()
+	defer resp.Body.Close()
 
-	// Теперь открываем страницу графика
-	scheduleURL := fmt.Sprintf("%s/%s/grafik?data_grafiku=%s&dyscyplina=1&strona=0", baseURL, courtID, date)
-	log.Printf("  → Fetching schedule page: %s", scheduleURL)
+	// Логируем статус и cookies для отладки
+	log.Printf("  → Response status: %d", resp.StatusCode)
+	if jar := client.Jar; jar != nil {
+		log.Printf("  → Using %d cookies", len(jar.Cookies(req.URL)))
+	}
 
-	req, err = http.NewRequest("GET", scheduleURL, nil)
+	if resp.StatusCode >= 400 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, 0, &statusError{code: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	req.Header.Set("User-Agent", userAgent)
+	return body, gen, nil
+}
 
-	resp, err = client.Do(req)
+// FetchPage загружает страницу kluby.org с автоматическим перелогином, если сессия истекла
+func (k *Kluby) FetchPage(ctx context.Context, pageURL string) ([]byte, error) {
+	body, gen, err := k.fetchPage(ctx, pageURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Логируем статус и cookies для отладки
-	log.Printf("  → Response status: %d", resp.StatusCode)
-	if jar := client.Jar; jar != nil {
-		cookies := jar.Cookies(req.URL)
-		log.Printf("  → Using %d cookies", len(cookies))
+	// Сессия истекла - перелогиниваемся и повторяем запрос один раз
+	if isLoggedOut(body) {
+		if err := k.session.relogin(ctx, gen); err == nil {
+			body, _, err = k.fetchPage(ctx, pageURL)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
+	return body, nil
+}
 
-	// Читаем и парсим HTML
-	bodyBytes, err := io.ReadAll(resp.Body)
+// FetchSchedule загружает график конкретного корта на заданную дату и возвращает все свободные слоты
+// sport - код вида спорта (определяет параметр dyscyplina)
+// courtID - ID корта (например "umacieja")
+// date - дата в формате "2025-11-05"
+// Фильтрация по времени выполняется на стороне checker
+func (k *Kluby) FetchSchedule(ctx context.Context, sport, courtID, date string) ([]types.Slot, error) {
+	schedule, err := k.fetchSchedule(ctx, sport, courtID, date)
 	if err != nil {
 		return nil, err
 	}
+	log.Printf("  → Found %d available slots for %s on %s", len(schedule.Slots), courtID, date)
+	return schedule.Slots, nil
+}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(bodyBytes)))
+// FetchBooked возвращает забронированные ячейки графика корта на дату (для слежения за отменами)
+func (k *Kluby) FetchBooked(ctx context.Context, sport, courtID, date string) ([]types.Slot, error) {
+	schedule, err := k.fetchSchedule(ctx, sport, courtID, date)
 	if err != nil {
 		return nil, err
 	}
+	return schedule.Booked, nil
+}
 
-	// Временная отладка для проблемных страниц
-	tableCount := doc.Find("table").Length()
-	rezerwujCount := doc.Find("a[href*='rezerwuj']").Length()
-
-	// Проверяем, требуется ли авторизация для просмотра графика
-	// Ищем конкретное сообщение, а не ссылку в меню
-	bodyStr := string(bodyBytes)
-	requiresLogin := strings.Contains(bodyStr, "Grafik widoczny po zalogowaniu") ||
-		strings.Contains(bodyStr, "widoczny po zalogowaniu") ||
-		strings.Contains(bodyStr, "Musisz się zalogować")
-
-	if requiresLogin {
-		log.Printf("  ⚠️ This court requires login - skipping (court: %s)", courtID)
-		return []types.Slot{}, nil
-	}
+// fetchSchedule загружает и разбирает страницу графика с учетом предохранителя клуба
+func (k *Kluby) fetchSchedule(ctx context.Context, sport, courtID, date string) (*Schedule, error) {
+	sport = types.SportOrDefault(sport)
 
-	// Если нет таблиц или ссылок, выводим часть HTML для отладки
-	if tableCount == 0 || rezerwujCount == 0 {
-		log.Printf("  ⚠️ Warning: Found %d tables, %d 'rezerwuj' links", tableCount, rezerwujCount)
-		log.Printf("  → No available slots found for this court")
+	// Открываем страницу графика (одна страница на корт и дату)
+	id := k.disciplineFor(sport)
+	scheduleURL := scheduleURLFor(courtID, date, id)
+	body, err := k.fetchSchedulePage(ctx, courtID, scheduleURL)
+	if err != nil {
+		return nil, err
 	}
 
-	slots := make([]types.Slot, 0)
-	seen := make(map[string]bool)
-
-	// Парсим название клуба из заголовка страницы
-	clubName := ""
-
-	// Пробуем извлечь из title
-	doc.Find("title").Each(func(i int, s *goquery.Selection) {
-		title := strings.TrimSpace(s.Text())
-		// Формат: "Nazwa Klubu - Rezerwacje ONLINE | Kluby.org"
-		if strings.Contains(title, " - ") {
-			parts := strings.Split(title, " - ")
-			if len(parts) > 0 {
-				clubName = strings.TrimSpace(parts[0])
+	// Сверяем номер дисциплины с переключателем на странице: ошибочный номер открыл бы чужой график
+	if found := ParseDisciplines(body); len(found) > 0 {
+		k.learnDisciplines(found)
+		actual, ok := found[sport]
+		switch {
+		case ok && actual != id:
+			scheduleURL = scheduleURLFor(courtID, date, actual)
+			if body, err = k.fetchSchedulePage(ctx, courtID, scheduleURL); err != nil {
+				return nil, err
 			}
+		case !ok && disciplineOwner(found, id) != "":
+			return nil, fmt.Errorf("%w: %s shows %s for dyscyplina=%d, no %s", ErrWrongDiscipline, courtID, disciplineOwner(found, id), id, sport)
 		}
-	})
-
-	// Если не нашли в title, ищем в заголовках
-	if clubName == "" {
-		doc.Find("h1, h2, h3").Each(func(i int, s *goquery.Selection) {
-			if clubName == "" {
-				text := strings.TrimSpace(s.Text())
-				if text != "" &&
-					!strings.Contains(strings.ToLower(text), "grafik") &&
-					!strings.Contains(strings.ToLower(text), "kluby.org") &&
-					len(text) > 3 {
-					clubName = text
-				}
-			}
-		})
 	}
 
-	if clubName == "" {
-		clubName = courtID // fallback
+	schedule, err := ParseSchedule(body, sport, courtID, date)
+	if err != nil {
+		return nil, err
 	}
+	for i := range schedule.Booked {
+		schedule.Booked[i].URL = scheduleURL
+	}
+	log.Printf("  → Club name: %s", schedule.ClubName)
 
-	log.Printf("  → Club name: %s", clubName)
-
-	// Ищем таблицу с графиком (она имеет id="grafik")
-	doc.Find("table#grafik").Each(func(i int, table *goquery.Selection) {
-		// Получаем заголовки столбцов (названия кортов) только из thead
-		courtTypes := make([]string, 0)
-		table.Find("thead tr").First().Find("th").Each(func(j int, th *goquery.Selection) {
-			// Берем только видимый текст, убираем все лишнее
-			courtType := strings.TrimSpace(th.Text())
-			// Убираем множественные пробелы и переносы строк
-			courtType = strings.Join(strings.Fields(courtType), " ")
-			courtTypes = append(courtTypes, courtType)
-		})
-
-		// Track rowspans: map[rowIndex][colIndex] = remainingRows
-		// When a cell has rowspan, it occupies the next N-1 rows in that column
-		rowspanTracker := make(map[int]map[int]int)
-
-		// Парсим строки таблицы (временные слоты)
-		table.Find("tbody tr, tr").Each(func(rowIndex int, tr *goquery.Selection) {
-			// Первая ячейка - время
-			timeCell := tr.Find("td").First()
-			slotTime := strings.TrimSpace(timeCell.Text())
-
-			// Проверяем формат времени (HH:MM)
-			if !strings.Contains(slotTime, ":") {
-				return
-			}
-
-			// Нормализуем время к формату HH:MM
-			slotTime = normalizeTime(slotTime)
-
-			// Initialize tracker for this row if needed
-			if rowspanTracker[rowIndex] == nil {
-				rowspanTracker[rowIndex] = make(map[int]int)
-			}
+	// Дополняем каталог клуба: число кортов, типы и покрытия
+	k.updateCatalog(courtID, schedule.ClubName, sport, schedule.Courts)
 
-			// Check if time is in range (but still process rowspans even if not)
-			inTimeRange := slotTime >= timeFrom && slotTime <= timeTo
-
-			// Get all physical cells in this row
-			cells := tr.Find("td")
-
-			// Track which logical column we're currently at
-			logicalColIndex := 0
-
-			// Iterate through physical cells
-			cells.Each(func(physicalIndex int, td *goquery.Selection) {
-				// Skip to next unoccupied logical column
-				for logicalColIndex < len(courtTypes) {
-					// Check if this logical column is occupied by a rowspan from a previous row
-					isOccupied := false
-					for prevRowIndex := 0; prevRowIndex < rowIndex; prevRowIndex++ {
-						if remaining, exists := rowspanTracker[prevRowIndex][logicalColIndex]; exists && remaining > (rowIndex-prevRowIndex) {
-							isOccupied = true
-							break
-						}
-					}
-
-					if !isOccupied {
-						break // Found next unoccupied column
-					}
-					logicalColIndex++ // This column is occupied, try next one
-				}
-
-				if logicalColIndex >= len(courtTypes) {
-					return // No more logical columns
-				}
-
-				// Now we have: physical cell 'td' maps to logical column 'logicalColIndex'
-
-				// First cell is time column - skip it
-				if logicalColIndex == 0 {
-					logicalColIndex++
-					return
-				}
-
-				// Check for rowspan attribute and track it
-				rowspanStr, hasRowspan := td.Attr("rowspan")
-				if hasRowspan {
-					rowspan := 1
-					fmt.Sscanf(rowspanStr, "%d", &rowspan)
-					if rowspan > 1 {
-						rowspanTracker[rowIndex][logicalColIndex] = rowspan
-					}
-				}
-
-				// Сначала проверяем текст ячейки на "Zarezerwowane"
-				cellText := strings.TrimSpace(td.Text())
-				if strings.Contains(cellText, "Zarezerwowane") ||
-					strings.Contains(cellText, "zarezerwowane") {
-					logicalColIndex++ // Move to next logical column for next physical cell
-					return            // Слот забронирован
-				}
-
-				// Ищем ссылку "Rezerwuj"
-				link := td.Find("a[href*='rezerwuj']")
-				if link.Length() == 0 {
-					logicalColIndex++ // Move to next logical column
-					return            // Слот занят или недоступен
-				}
-
-				linkText := strings.TrimSpace(link.Text())
-				if !strings.Contains(strings.ToLower(linkText), "rezerwuj") {
-					logicalColIndex++
-					return
-				}
-
-				// Only create slots for rows in the time range
-				if !inTimeRange {
-					logicalColIndex++
-					return
-				}
-
-				// Получаем href для бронирования
-				href, exists := link.Attr("href")
-				if !exists {
-					logicalColIndex++
-					return
-				}
-
-				// Определяем тип корта из заголовка столбца
-				courtType := ""
-				if logicalColIndex < len(courtTypes) {
-					courtType = courtTypes[logicalColIndex]
-				}
-
-				// Пропускаем открытые корты (проверяем ДО очистки!)
-				courtTypeLower := strings.ToLower(courtType)
-				if strings.Contains(courtTypeLower, "otwarte") ||
-					strings.Contains(courtTypeLower, "odkryte") ||
-					strings.Contains(courtTypeLower, "odkryt") ||
-					strings.Contains(courtTypeLower, "otwart") {
-					logicalColIndex++
-					return
-				}
-
-				// Очищаем название корта
-				courtType = cleanCourtName(courtType)
-
-				// Создаем слот
-				slot := types.Slot{
-					ClubID:    courtID,
-					ClubName:  clubName,
-					CourtType: courtType,
-					TypeID:    courtID, // используем courtID как typeID
-					Date:      date,
-					Time:      slotTime,
-					Duration:  2, // по умолчанию 2 часа
-					Price:     "0,00",
-					URL:       baseURL + href,
-				}
-
-				// Дедупликация
-				uniqueID := slot.UniqueID()
-				if !seen[uniqueID] {
-					slots = append(slots, slot)
-					seen[uniqueID] = true
-				}
-
-				// Move to next logical column for next physical cell
-				logicalColIndex++
-			})
-		})
-	})
-
-	log.Printf("  → Found %d available slots for %s on %s (time range: %s-%s)", len(slots), courtID, date, timeFrom, timeTo)
-	return slots, nil
+	return schedule, nil
 }
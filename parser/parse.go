@@ -0,0 +1,407 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"court-bot/types"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Разбор HTML страниц kluby.org отделен от загрузки, чтобы его можно было
+// проверять на сохраненных страницах (см. testdata/recorded и cmd/record-fixtures)
+// и на написанных вручную примерах разметки (testdata/synthetic)
+
+// Schedule - результат разбора страницы графика
+type Schedule struct {
+	ClubName string       // название клуба из заголовка страницы
+	Courts   []string     // заголовки столбцов кортов (без столбца времени)
+	Slots    []types.Slot // свободные слоты
+	Booked   []types.Slot // забронированные ячейки ("Zarezerwowane") - для слежения за отменами, без цены
+}
+
+// DistrictsURL возвращает адрес страницы со списком районов города
+func DistrictsURL(city, sport string) string {
+	return fmt.Sprintf("%s/%s/kluby/%s", baseURL, types.SportOrDefault(sport), types.CityOrDefault(city))
+}
+
+// ClubListURL возвращает адрес страницы района со списком клубов: /[sport]/kluby/[city]/[slug]
+func ClubListURL(city, sport, district string) string {
+	return fmt.Sprintf("%s/%s/kluby/%s/%s", baseURL, types.SportOrDefault(sport), types.CityOrDefault(city), districtToSlug(district))
+}
+
+// ScheduleURL возвращает адрес страницы графика корта на дату (с номером дисциплины по умолчанию)
+func ScheduleURL(sport, courtID, date string) string {
+	return scheduleURLFor(courtID, date, disciplineID(sport))
+}
+
+func scheduleURLFor(courtID, date string, discipline int) string {
+	return fmt.Sprintf("%s/%s/grafik?data_grafiku=%s&dyscyplina=%d&strona=0", baseURL, courtID, date, discipline)
+}
+
+// ParseDisciplines извлекает номера dyscyplina из переключателя дисциплин на странице графика:
+// ссылки "...&dyscyplina=4" или <select name="dyscyplina"> с названием вида спорта в тексте
+// Возвращает пустую карту, если переключателя нет (у клуба одна дисциплина)
+func ParseDisciplines(body []byte) map[string]int {
+	found := make(map[string]int)
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return found
+	}
+
+	add := func(value, label string) {
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return
+		}
+		if sport := sportByName(label); sport != "" {
+			if _, ok := found[sport]; !ok {
+				found[sport] = id
+			}
+		}
+	}
+
+	doc.Find("select[name='dyscyplina'] option").Each(func(_ int, s *goquery.Selection) {
+		value, _ := s.Attr("value")
+		add(value, s.Text())
+	})
+	doc.Find("a[href*='dyscyplina=']").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		u, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		add(u.Query().Get("dyscyplina"), s.Text())
+	})
+	return found
+}
+
+// sportByName определяет вид спорта по подписи дисциплины ("Tenis ziemny", "Padel", "Squash")
+func sportByName(label string) string {
+	l := strings.ToLower(label)
+	switch {
+	case strings.Contains(l, "stołow") || strings.Contains(l, "stolow"):
+		return "" // tenis stołowy - настольный теннис
+	case strings.Contains(l, "pickleball"):
+		return types.SportPickleball
+	case strings.Contains(l, "padel"):
+		return types.SportPadel
+	case strings.Contains(l, "squash"):
+		return types.SportSquash
+	case strings.Contains(l, "badminton"):
+		return types.SportBadminton
+	case strings.Contains(l, "tenis"):
+		return types.SportTennis
+	}
+	return ""
+}
+
+// ParseDistricts извлекает названия районов со страницы списка районов города
+func ParseDistricts(body []byte, city, sport string) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	districtsPath := fmt.Sprintf("/%s/kluby/%s/", types.SportOrDefault(sport), types.CityOrDefault(city))
+	districts := make([]string, 0)
+	seen := make(map[string]bool)
+
+	// Ищем ссылки на районы в "Lista dzielnic"
+	doc.Find("a[href*='" + districtsPath + "']").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		// Извлекаем название района из текста ссылки
+		district := strings.TrimSpace(s.Text())
+
+		// Проверяем что это действительно район (не пустое и имеет правильный формат URL /sport/kluby/city/district)
+		idx := strings.Index(href, districtsPath)
+		if idx == -1 || strings.Trim(href[idx+len(districtsPath):], "/") == "" {
+			return
+		}
+		if district != "" && !seen[district] {
+			districts = append(districts, district)
+			seen[district] = true
+		}
+	})
+
+	return districts, nil
+}
+
+// ParseClubList извлекает клубы со страницы района
+func ParseClubList(body []byte, city, sport, district string) ([]types.Court, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	courts := make([]types.Court, 0)
+	seen := make(map[string]bool)
+
+	// Парсим карточки кортов
+	// Структура: <a href="/court-name"><img/><h4>Name</h4><p>Address</p></a>
+	// Категории спорта: <a href="/sport/..."><img/><h3>SPORT_NAME</h3></a>
+	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		// Пропускаем внешние ссылки и служебные страницы
+		if strings.HasPrefix(href, "http") ||
+			strings.HasPrefix(href, "//") ||
+			strings.Contains(href, "regulamin") ||
+			strings.Contains(href, "static") ||
+			href == "/" || href == "" {
+			return
+		}
+
+		// Пропускаем ссылки на спортивные категории и навигацию (они имеют формат /sport/kluby/...)
+		if strings.Contains(href, "/tenis/") ||
+			strings.Contains(href, "/padel/") ||
+			strings.Contains(href, "/squash/") ||
+			strings.Contains(href, "/badminton/") ||
+			strings.Contains(href, "/pickleball/") ||
+			strings.Contains(href, "/golf/") ||
+			strings.Contains(href, "/bilard/") {
+			return
+		}
+
+		// Пропускаем навигационные ссылки
+		if strings.Contains(href, "/kluby/") {
+			return
+		}
+
+		// Пропускаем события, турниры и другие пути (они содержат "/" в href после первого символа)
+		// Корты имеют простой формат: /court-slug (одно слово или слова через дефис)
+		// События/турниры: /zapisy/123, /turnieje/456
+		trimmedHref := strings.TrimPrefix(href, "/")
+		if strings.Contains(trimmedHref, "/") {
+			return
+		}
+
+		// Категории спорта используют h3, корты используют h4
+		// Пропускаем все ссылки с h3 (это категории спорта)
+		if s.Find("h3").Length() > 0 {
+			return
+		}
+
+		// Проверяем что внутри есть h4 (название корта)
+		heading := s.Find("h4").First()
+		if heading.Length() == 0 {
+			return
+		}
+
+		// Извлекаем название корта
+		name := strings.TrimSpace(heading.Text())
+		if name == "" || len(name) < 3 {
+			return
+		}
+
+		// Пропускаем подозрительно короткие названия (обычно тестовые/неактивные корты)
+		// Реальные корты имеют нормальные названия типа "Park Tennis Academy", "OSIR Bemowo"
+		if len(name) <= 4 {
+			// Исключения: известные короткие названия могут быть добавлены сюда если нужно
+			return
+		}
+
+		// Пропускаем названия с датами в скобках - это события (например "(2026-03-01)")
+		if strings.Contains(name, "(202") {
+			return
+		}
+
+		// Проверяем возможно ли резервация
+		reservation := s.Find("span").First()
+		if reservation.Text() != "REZERWUJ" {
+			return
+		}
+
+		// Извлекаем адрес (если есть)
+		address := ""
+		addressPara := s.Find("p").First()
+		if addressPara.Length() > 0 {
+			address = strings.TrimSpace(addressPara.Text())
+		}
+
+		// Фильтруем по расстоянию - если больше 50 км, вероятно ошибка
+		distance, hasDistance := parseDistance(address)
+		if hasDistance && distance > 50.0 {
+			return // Пропускаем корты дальше 50 км (например asd2 с 6129 км)
+		}
+
+		// Очищаем href от query параметров
+		courtID := strings.Split(href, "?")[0]
+		courtID = strings.TrimPrefix(courtID, "/")
+
+		// Проверяем дубликаты
+		if courtID == "" || seen[courtID] {
+			return
+		}
+
+		seen[courtID] = true
+		court := types.Court{
+			ID:         courtID,
+			Name:       name,
+			City:       city,
+			District:   district,
+			Address:    stripDistance(address),
+			DistanceKm: distance,
+			URL:        baseURL + "/" + courtID,
+			Sports:     []string{sport},
+		}
+
+		courts = append(courts, court)
+	})
+
+	return courts, nil
+}
+
+// ParseSchedule разбирает страницу графика корта и возвращает все свободные слоты
+func ParseSchedule(body []byte, sport, courtID, date string) (*Schedule, error) {
+	sport = types.SportOrDefault(sport)
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// Без таблицы графика разбирать нечего - скорее всего kluby.org поменял разметку
+	tables := doc.Find("table#grafik")
+	if tables.Length() == 0 {
+		return nil, fmt.Errorf("%w: no schedule table on %s/%s (%d tables on page)", ErrLayoutChanged, courtID, date, doc.Find("table").Length())
+	}
+
+	slots := make([]types.Slot, 0)
+	booked := make([]types.Slot, 0)
+	courts := make([]string, 0)
+	seen := make(map[string]bool)
+
+	// Парсим название клуба из заголовка страницы
+	clubName := ""
+
+	// Пробуем извлечь из title
+	doc.Find("title").Each(func(i int, s *goquery.Selection) {
+		title := strings.TrimSpace(s.Text())
+		// Формат: "Nazwa Klubu - Rezerwacje ONLINE | Kluby.org"
+		if strings.Contains(title, " - ") {
+			parts := strings.Split(title, " - ")
+			if len(parts) > 0 {
+				clubName = strings.TrimSpace(parts[0])
+			}
+		}
+	})
+
+	// Если не нашли в title, ищем в заголовках
+	if clubName == "" {
+		doc.Find("h1, h2, h3").Each(func(i int, s *goquery.Selection) {
+			if clubName == "" {
+				text := strings.TrimSpace(s.Text())
+				if text != "" &&
+					!strings.Contains(strings.ToLower(text), "grafik") &&
+					!strings.Contains(strings.ToLower(text), "kluby.org") &&
+					len(text) > 3 {
+					clubName = text
+				}
+			}
+		})
+	}
+
+	if clubName == "" {
+		clubName = courtID // fallback
+	}
+
+	// Ищем таблицу с графиком (она имеет id="grafik")
+	timedRows := 0
+	tables.Each(func(i int, table *goquery.Selection) {
+		// Получаем заголовки столбцов (названия кортов) только из thead
+		courtTypes := make([]string, 0)
+		table.Find("thead tr").First().Find("th").Each(func(j int, th *goquery.Selection) {
+			// Берем только видимый текст, убираем все лишнее
+			courtType := strings.TrimSpace(th.Text())
+			// Убираем множественные пробелы и переносы строк
+			courtType = strings.Join(strings.Fields(courtType), " ")
+			courtTypes = append(courtTypes, courtType)
+		})
+
+		if len(courtTypes) > 1 {
+			courts = append(courts, courtTypes[1:]...)
+		}
+
+		// Раскладываем таблицу в матрицу с учетом rowspan/colspan
+		g := buildGrid(table)
+		times := g.times()
+
+		// Шаг сетки (длительность одной ячейки) в минутах
+		granularity := detectGranularity(times)
+
+		// newSlot создает слот для столбца с заголовком header
+		newSlot := func(header, start string, duration int) types.Slot {
+			return types.Slot{
+				Sport:       sport,
+				ClubID:      courtID,
+				ClubName:    clubName,
+				CourtType:   cleanCourtName(header),
+				Environment: classifyEnvironment(header), // определяем ДО очистки названия
+				TypeID:      courtID,                     // используем courtID как typeID
+				Date:        date,
+				Time:        start,
+				Duration:    duration,
+			}
+		}
+
+		// Обходим матрицу построчно; каждая ячейка учитывается один раз - в своей верхней левой позиции
+		for r, row := range g.Cells {
+			if times[r] == "" {
+				continue // строка без времени
+			}
+			timedRows++
+			for c := 1; c < len(row) && c < len(courtTypes); c++ {
+				cell := row[c]
+				if cell == nil || cell.Row != r || cell.Col != c {
+					continue
+				}
+				switch cell.State {
+				case cellFree:
+					slot := newSlot(courtTypes[c], times[r], cell.RowSpan*granularity) // свободная ячейка может занимать несколько строк
+					// Цену ячейки приводим к цене за час, чтобы сравнивать ее с лимитом подписки
+					slot.Price = cell.Price
+					if !cell.Hourly {
+						slot.Price = cell.Price.PerHour(slot.Duration)
+					}
+					slot.URL = baseURL + cell.Href
+
+					// Дедупликация
+					uniqueID := slot.UniqueID()
+					if !seen[uniqueID] {
+						slots = append(slots, slot)
+						seen[uniqueID] = true
+					}
+
+				case cellBooked:
+					// Бронь может занимать несколько кортов (colspan) - учитываем каждый
+					for cc := c; cc < c+cell.ColSpan && cc < len(courtTypes); cc++ {
+						slot := newSlot(courtTypes[cc], times[r], cell.RowSpan*granularity)
+						slot.URL = ScheduleURL(sport, courtID, date) // при загрузке заменяется фактическим адресом страницы
+						booked = append(booked, slot)
+					}
+				}
+			}
+		}
+	})
+
+	if len(courts) == 0 {
+		return nil, fmt.Errorf("%w: schedule table has no court columns on %s/%s", ErrLayoutChanged, courtID, date)
+	}
+	if timedRows == 0 {
+		return nil, fmt.Errorf("%w: no time rows on %s/%s", ErrEmptyGrid, courtID, date)
+	}
+
+	return &Schedule{ClubName: clubName, Courts: courts, Slots: slots, Booked: booked}, nil
+}
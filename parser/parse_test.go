@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"court-bot/types"
+)
+
+// Golden-тесты разбора страниц kluby.org. Эталоны обновляются через
+// go test ./parser -update (после обновления diff эталонов нужно просмотреть глазами).
+//
+// testdata/synthetic - короткие страницы, написанные вручную под случаи разбора (rowspan, цены,
+// типы кортов). Это не настоящая разметка kluby.org: смену верстки сайта они не поймают.
+// testdata/recorded - настоящие страницы, записанные go run ./cmd/record-fixtures
+// (перед коммитом проверить, что в них не осталось личных данных аккаунта).
+var update = flag.Bool("update", false, "перезаписать golden-файлы в testdata")
+
+// fixtureDirs - каталоги фикстур внутри testdata
+var fixtureDirs = []string{"synthetic", "recorded"}
+
+func TestParseGolden(t *testing.T) {
+	var fixtures []string
+	for _, dir := range fixtureDirs {
+		found, err := filepath.Glob(filepath.Join("testdata", dir, "*.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixtures = append(fixtures, found...)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata")
+	}
+
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixture), ".html")
+		t.Run(filepath.Base(filepath.Dir(fixture))+"/"+name, func(t *testing.T) {
+			body, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := parseFixture(name, body)
+			if err != nil {
+				t.Fatalf("parse %s: %v", name, err)
+			}
+
+			data, err := json.MarshalIndent(got, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, '\n')
+
+			golden := strings.TrimSuffix(fixture, ".html") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(golden, data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create): %v", err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("%s mismatch (run with -update if the change is expected)\n--- got ---\n%s\n--- want ---\n%s", golden, data, want)
+			}
+		})
+	}
+}
+
+// TestRecordedFixtures проверяет, что в testdata/recorded есть настоящие страницы каждого вида:
+// без них golden-тесты проверяют только синтетическую разметку
+func TestRecordedFixtures(t *testing.T) {
+	var missing []string
+	for _, kind := range []string{"schedule", "districts", "clubs"} {
+		found, err := filepath.Glob(filepath.Join("testdata", "recorded", kind+"--*.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) == 0 {
+			missing = append(missing, kind)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("no recorded kluby.org pages for %s (see testdata/recorded/README.md)", strings.Join(missing, ", "))
+	if os.Getenv("REQUIRE_RECORDED_FIXTURES") != "" {
+		t.Fatal(msg)
+	}
+	t.Skip(msg)
+}
+
+// parseFixture выбирает парсер по имени файла (см. cmd/record-fixtures):
+// districts--<city>--<sport>, clubs--<city>--<sport>--<district>, schedule--<sport>--<club>--<date>
+func parseFixture(name string, body []byte) (interface{}, error) {
+	parts := strings.Split(name, "--")
+	switch {
+	case parts[0] == "districts" && len(parts) == 3:
+		return ParseDistricts(body, parts[1], parts[2])
+	case parts[0] == "clubs" && len(parts) == 4:
+		return ParseClubList(body, parts[1], parts[2], parts[3])
+	case parts[0] == "schedule" && len(parts) == 4:
+		return ParseSchedule(body, parts[1], parts[2], parts[3])
+	}
+	return nil, fmt.Errorf("unknown fixture name format: %s", name)
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want error
+	}{
+		{"no table", `<html><body><div class="grafik-v2"></div></body></html>`, ErrLayoutChanged},
+		{"no headers", `<table id="grafik"><tr><td>7:00</td><td>x</td></tr></table>`, ErrLayoutChanged},
+		{"no rows", `<table id="grafik"><thead><tr><th>Godzina</th><th>Kort 1</th></tr></thead><tbody></tbody></table>`, ErrEmptyGrid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSchedule([]byte(tt.html), "tenis", "klub", "2025-11-05")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDisciplines(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want map[string]int
+	}{
+		{"no switcher", `<table id="grafik"></table>`, map[string]int{}},
+		{
+			"links",
+			`<a href="/klub/grafik?data_grafiku=2025-11-05&dyscyplina=1&strona=0">Tenis</a>
+			 <a href="/klub/grafik?data_grafiku=2025-11-05&amp;dyscyplina=7&amp;strona=0">Padel</a>
+			 <a href="/klub/grafik?dyscyplina=9">Tenis stołowy</a>`,
+			map[string]int{"tenis": 1, "padel": 7},
+		},
+		{
+			"select",
+			`<select name="dyscyplina"><option value="2">Squash</option><option value="3">Badminton</option><option value="x">Pickleball</option></select>`,
+			map[string]int{"squash": 2, "badminton": 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDisciplines([]byte(tt.html))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseDisciplines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyEnvironment(t *testing.T) {
+	tests := map[string]string{
+		"Kort 3 ziemny otwart Korty odkryte": types.EnvOutdoor,
+		"Kort 1 Balon":                       types.EnvBubble,
+		"Kort 2 Namiot":                      types.EnvBubble,
+		"Kort 4 hala pneumatyczna":           types.EnvBubble,
+		"Kort 5 zadaszony":                   types.EnvIndoor,
+		"Hala 1 Hala tenis":                  types.EnvIndoor,
+	}
+	for header, want := range tests {
+		if got := classifyEnvironment(header); got != want {
+			t.Errorf("classifyEnvironment(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tokenBucket - потокобезопасный ограничитель частоты запросов к kluby.org (общий для всех воркеров)
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // токенов в секунду
+	capacity float64 // максимальный размер всплеска
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket создает ограничитель на rate запросов в секунду со всплеском до burst запросов
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		rate = 2
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait блокируется до получения токена или отмены контекста
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		// Сколько ждать до появления следующего токена
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	retryAttempts  = 3                // попыток на один запрос (включая первую)
+	retryBaseDelay = 1 * time.Second  // пауза перед второй попыткой, дальше удваивается
+	retryMaxDelay  = 30 * time.Second // верхняя граница паузы, в том числе для Retry-After
+)
+
+// statusError - ответ kluby.org с ошибочным HTTP статусом
+type statusError struct {
+	code       int
+	retryAfter time.Duration // из заголовка Retry-After (0 если не указан)
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// retryable сообщает, имеет ли смысл повторить запрос: 5xx, 429 и таймауты сети
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// retryDelay возвращает паузу перед попыткой attempt (1 - первая повторная): Retry-After или экспонента с разбросом
+func retryDelay(err error, attempt int) time.Duration {
+	var se *statusError
+	if errors.As(err, &se) && se.retryAfter > 0 {
+		return min(se.retryAfter, retryMaxDelay)
+	}
+	delay := retryBaseDelay << (attempt - 1)
+	delay += time.Duration(rand.Int63n(int64(delay) / 2)) // разброс, чтобы воркеры не повторяли синхронно
+	return min(delay, retryMaxDelay)
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: число секунд или HTTP дата
+func parseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// sleepCtx ждет d или отмены ctx
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
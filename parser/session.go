@@ -0,0 +1,310 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	loginPath = "/logowanie"
+
+	// loginAlertThreshold - после скольких неудачных входов подряд уведомлять администратора
+	loginAlertThreshold = 3
+	// loginAlertInterval - не чаще одного уведомления за этот интервал
+	loginAlertInterval = time.Hour
+)
+
+// errNoCredentials - автоматический вход невозможен без KLUBY_EMAIL / KLUBY_PASSWORD
+var errNoCredentials = errors.New("kluby.org credentials are not configured")
+
+// savedCookie - cookie сессии в Redis (kluby.org выставляет их на весь домен)
+type savedCookie struct {
+	Name  string
+	Value string
+}
+
+// session управляет авторизацией на kluby.org:
+// восстанавливает cookies из Redis (или KLUBY_ORG/KLUBY_AUTOLOG), входит по логину и паролю
+// и прозрачно перелогинивается, когда kluby.org показывает страницу для неавторизованных
+type session struct {
+	email    string
+	password string
+	store    Storage
+	limiter  *tokenBucket
+
+	mu         sync.Mutex
+	client     *http.Client
+	generation int // увеличивается при каждом успешном входе
+	failures   int // неудачные входы подряд
+	lastAlert  time.Time
+	onFailure  func(err error, failures int)
+}
+
+func newSession(store Storage, limiter *tokenBucket, email, password string) *session {
+	return &session{
+		email:    email,
+		password: password,
+		store:    store,
+		limiter:  limiter,
+	}
+}
+
+// get возвращает авторизованный клиент и номер поколения сессии (для relogin)
+func (s *session) get(ctx context.Context) (*http.Client, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		return s.client, s.generation, nil
+	}
+
+	log.Println("🔐 Initializing kluby.org session...")
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.client = &http.Client{
+		Jar:     jar,
+		Timeout: 15 * time.Second,
+	}
+
+	// 1. Cookies из Redis (сохраненные после прошлого входа)
+	if s.restore() {
+		log.Println("✅ Restored kluby.org session from Redis")
+		return s.client, s.generation, nil
+	}
+
+	// 2. Статические cookies из окружения
+	if os.Getenv("KLUBY_ORG") != "" || os.Getenv("KLUBY_AUTOLOG") != "" {
+		s.setCookies([]savedCookie{
+			{Name: "kluby_org", Value: os.Getenv("KLUBY_ORG")},
+			{Name: "kluby_autolog", Value: os.Getenv("KLUBY_AUTOLOG")},
+			{Name: "kluby_remember", Value: "1"},
+		})
+		log.Println("✅ Using kluby.org cookies from environment")
+		return s.client, s.generation, nil
+	}
+
+	// 3. Входим по логину и паролю
+	if err := s.loginLocked(ctx); err != nil {
+		log.Printf("⚠️ Initial kluby.org login failed: %v (continuing anonymously)", err)
+	}
+	return s.client, s.generation, nil
+}
+
+// relogin выполняет повторный вход, если сессия поколения gen оказалась разлогинена
+// Если другой воркер уже перелогинился (поколение сменилось), ничего не делает
+func (s *session) relogin(ctx context.Context, gen int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.generation != gen {
+		return nil
+	}
+
+	log.Println("🔐 kluby.org session expired, logging in again...")
+	return s.loginLocked(ctx)
+}
+
+// loginLocked входит на kluby.org (вызывается под s.mu)
+func (s *session) loginLocked(ctx context.Context) error {
+	err := s.login(ctx)
+	if err != nil {
+		s.failures++
+		log.Printf("❌ kluby.org login failed (%d in a row): %v", s.failures, err)
+		if s.onFailure != nil && s.failures >= loginAlertThreshold && time.Since(s.lastAlert) >= loginAlertInterval {
+			s.lastAlert = time.Now()
+			go s.onFailure(err, s.failures)
+		}
+		return err
+	}
+
+	s.failures = 0
+	s.generation++
+	s.persist()
+	log.Println("✅ Logged in to kluby.org")
+	return nil
+}
+
+// login отправляет форму входа: находит форму с полем пароля, сохраняет скрытые поля и подставляет учетные данные
+func (s *session) login(ctx context.Context) error {
+	if s.email == "" || s.password == "" {
+		return errNoCredentials
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	loginURL := baseURL + loginPath
+	req, err := http.NewRequestWithContext(ctx, "GET", loginURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var form *goquery.Selection
+	doc.Find("form").EachWithBreak(func(i int, f *goquery.Selection) bool {
+		if f.Find("input[type='password']").Length() > 0 {
+			form = f
+			return false
+		}
+		return true
+	})
+	if form == nil {
+		return fmt.Errorf("login form not found on %s", loginURL)
+	}
+
+	values := url.Values{}
+	form.Find("input").Each(func(i int, input *goquery.Selection) {
+		name, ok := input.Attr("name")
+		if !ok || name == "" {
+			return
+		}
+		inputType := strings.ToLower(input.AttrOr("type", "text"))
+		nameLower := strings.ToLower(name)
+
+		switch {
+		case inputType == "password":
+			values.Set(name, s.password)
+		case inputType == "email" || strings.Contains(nameLower, "email") || strings.Contains(nameLower, "login"):
+			values.Set(name, s.email)
+		case inputType == "checkbox":
+			// Отмечаем "запомнить меня", чтобы получить долгоживущие cookies
+			if strings.Contains(nameLower, "zapamietaj") || strings.Contains(nameLower, "remember") {
+				values.Set(name, input.AttrOr("value", "1"))
+			}
+		case inputType == "submit" || inputType == "button":
+		default:
+			values.Set(name, input.AttrOr("value", ""))
+		}
+	})
+
+	action, err := resp.Request.URL.Parse(form.AttrOr("action", loginPath))
+	if err != nil {
+		return err
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	post, err := http.NewRequestWithContext(ctx, "POST", action.String(), strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	post.Header.Set("User-Agent", userAgent)
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	postResp, err := s.client.Do(post)
+	if err != nil {
+		return err
+	}
+	defer postResp.Body.Close()
+
+	body, err := io.ReadAll(postResp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Если после отправки снова видим форму входа - учетные данные не приняты
+	if strings.Contains(string(body), "type=\"password\"") || isLoggedOut(body) {
+		return fmt.Errorf("login rejected (status %d)", postResp.StatusCode)
+	}
+	return nil
+}
+
+// isLoggedOut проверяет, что kluby.org показал страницу для неавторизованного пользователя
+// Ищем конкретное сообщение, а не ссылку "Zaloguj" в меню
+func isLoggedOut(body []byte) bool {
+	text := string(body)
+	return strings.Contains(text, "Grafik widoczny po zalogowaniu") ||
+		strings.Contains(text, "widoczny po zalogowaniu") ||
+		strings.Contains(text, "Musisz się zalogować")
+}
+
+// restore загружает cookies сессии из Redis
+func (s *session) restore() bool {
+	if s.store == nil {
+		return false
+	}
+	data, err := s.store.GetSession()
+	if err != nil || data == nil {
+		return false
+	}
+	var cookies []savedCookie
+	if err := json.Unmarshal(data, &cookies); err != nil || len(cookies) == 0 {
+		return false
+	}
+	s.setCookies(cookies)
+	return true
+}
+
+// save сохраняет cookies сессии в Redis (потокобезопасная обертка над persist)
+func (s *session) save() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persist()
+}
+
+// persist сохраняет текущие cookies сессии в Redis, чтобы пережить перезапуск
+func (s *session) persist() {
+	if s.store == nil || s.client == nil {
+		return
+	}
+	u, _ := url.Parse(baseURL)
+	cookies := make([]savedCookie, 0)
+	for _, c := range s.client.Jar.Cookies(u) {
+		cookies = append(cookies, savedCookie{Name: c.Name, Value: c.Value})
+	}
+	if len(cookies) == 0 {
+		return
+	}
+	data, err := json.Marshal(cookies)
+	if err != nil {
+		return
+	}
+	if err := s.store.SaveSession(data); err != nil {
+		log.Printf("⚠️ Failed to persist kluby.org session: %v", err)
+	}
+}
+
+// setCookies устанавливает cookies на весь домен kluby.org
+func (s *session) setCookies(saved []savedCookie) {
+	u, _ := url.Parse(baseURL)
+	cookies := make([]*http.Cookie, 0, len(saved))
+	for _, c := range saved {
+		cookies = append(cookies, &http.Cookie{
+			Name:   c.Name,
+			Value:  c.Value,
+			Domain: ".kluby.org",
+			Path:   "/",
+		})
+	}
+	s.client.Jar.SetCookies(u, cookies)
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"court-bot/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ===== Жизненный цикл слотов =====
+//
+// life:<UniqueID>                 - JSON types.SlotLifecycle (живет 30 дней после начала слота)
+// life:page:<sport>:<court>:<date> - множество UniqueID слотов, свободных на странице при последней проверке
+// life:seen:<sport>:<court>:<date> - страница уже проверялась (множество выше пустое, если свободных слотов нет)
+// life:index                      - ZSET UniqueID по времени начала слота (для аналитики)
+
+// lifecycleRetention - сколько хранить историю слота после его начала
+const lifecycleRetention = 30 * 24 * time.Hour
+
+const lifecycleIndexKey = "life:index"
+
+func lifecycleKey(id string) string {
+	return "life:" + id
+}
+
+// slotStart - время начала слота (без учета часового пояса города, для TTL и индекса этого достаточно)
+func slotStart(slot types.Slot) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", slot.Date+" "+slot.Time)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// ObserveSchedulePage обновляет жизненный цикл слотов одной страницы графика:
+// новые получают first-seen, исчезнувшие - disappeared-at, вернувшиеся - новый период доступности
+func (s *Storage) ObserveSchedulePage(sport, courtID, date string, slots []types.Slot, now time.Time) error {
+	pageKey := fmt.Sprintf("life:page:%s:%s:%s", types.SportOrDefault(sport), courtID, date)
+	seenKey := fmt.Sprintf("life:seen:%s:%s:%s", types.SportOrDefault(sport), courtID, date)
+
+	prevIDs, err := s.client.SMembers(ctx, pageKey).Result()
+	if err != nil {
+		return err
+	}
+	// Слоты, свободные уже при первой проверке страницы, появились когда-то раньше - когда, неизвестно
+	seen, err := s.client.Exists(ctx, seenKey).Result()
+	if err != nil {
+		return err
+	}
+	firstCheck := seen == 0
+
+	current := make(map[string]types.Slot, len(slots))
+	for _, slot := range slots {
+		current[slot.UniqueID()] = slot
+	}
+
+	ids := make([]string, 0, len(current)+len(prevIDs))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	for _, id := range prevIDs {
+		if _, ok := current[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return s.client.Set(ctx, seenKey, 1, lifecycleRetention).Err()
+	}
+
+	records, err := s.GetLifecycles(ids)
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	for _, id := range ids {
+		rec := records[id]
+		slot, present := current[id]
+
+		switch {
+		case present && rec == nil:
+			rec = &types.SlotLifecycle{Slot: slot, FirstSeen: now, AppearedAt: now, Appearances: 1, Preexisting: firstCheck}
+		case present && !rec.Available():
+			// Слот снова свободен (например, бронь отменили)
+			rec.DisappearedAt = time.Time{}
+			rec.AppearedAt = now
+			rec.Appearances++
+		case !present && rec != nil && rec.Available():
+			rec.DisappearedAt = now
+		}
+		if rec == nil {
+			continue // запись уже истекла
+		}
+		if present {
+			rec.Slot = slot
+			rec.LastSeen = now
+		}
+
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		start := slotStart(rec.Slot)
+		ttl := time.Until(start.Add(lifecycleRetention))
+		if ttl < time.Hour {
+			ttl = time.Hour
+		}
+		pipe.Set(ctx, lifecycleKey(id), data, ttl)
+		pipe.ZAdd(ctx, lifecycleIndexKey, redis.Z{Score: float64(start.Unix()), Member: id})
+	}
+
+	pipe.Set(ctx, seenKey, 1, lifecycleRetention)
+	pipe.Del(ctx, pageKey)
+	if len(current) > 0 {
+		members := make([]interface{}, 0, len(current))
+		for id := range current {
+			members = append(members, id)
+		}
+		pipe.SAdd(ctx, pageKey, members...)
+		pipe.Expire(ctx, pageKey, lifecycleRetention)
+	}
+
+	// Старые записи индекса больше не нужны
+	cutoff := now.Add(-lifecycleRetention).Unix()
+	pipe.ZRemRangeByScore(ctx, lifecycleIndexKey, "-inf", strconv.FormatInt(cutoff, 10))
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// GetLifecycles получает записи жизненного цикла по UniqueID (отсутствующие не попадают в результат)
+func (s *Storage) GetLifecycles(ids []string) (map[string]*types.SlotLifecycle, error) {
+	result := make(map[string]*types.SlotLifecycle, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = lifecycleKey(id)
+	}
+	vals, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, val := range vals {
+		str, ok := val.(string)
+		if !ok {
+			continue
+		}
+		var rec types.SlotLifecycle
+		if err := json.Unmarshal([]byte(str), &rec); err != nil {
+			continue
+		}
+		result[ids[i]] = &rec
+	}
+	return result, nil
+}
+
+// ListLifecycles возвращает историю слотов, начинающихся в интервале [from, to]
+func (s *Storage) ListLifecycles(from, to time.Time) ([]types.SlotLifecycle, error) {
+	ids, err := s.client.ZRangeByScore(ctx, lifecycleIndexKey, &redis.ZRangeBy{
+		Min: strconv.FormatInt(from.Unix(), 10),
+		Max: strconv.FormatInt(to.Unix(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]types.SlotLifecycle, 0, len(ids))
+	// MGET пачками, чтобы не собирать огромную команду
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		records, err := s.GetLifecycles(ids[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids[start:end] {
+			if rec, ok := records[id]; ok {
+				list = append(list, *rec)
+			}
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ===== Расписание опроса страниц графика =====
+//
+// poll:due - ZSET "sport:courtID:date" -> unix-время следующей проверки страницы
+
+const pollDueKey = "poll:due"
+
+// GetPollDue возвращает время следующей проверки для всех известных страниц графика
+func (s *Storage) GetPollDue() (map[string]time.Time, error) {
+	entries, err := s.client.ZRangeWithScores(ctx, pollDueKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	due := make(map[string]time.Time, len(entries))
+	for _, z := range entries {
+		if member, ok := z.Member.(string); ok {
+			due[member] = time.Unix(int64(z.Score), 0)
+		}
+	}
+	return due, nil
+}
+
+// SetPollDue записывает время следующей проверки для страниц графика
+func (s *Storage) SetPollDue(due map[string]time.Time) error {
+	if len(due) == 0 {
+		return nil
+	}
+	members := make([]redis.Z, 0, len(due))
+	for member, at := range due {
+		members = append(members, redis.Z{Score: float64(at.Unix()), Member: member})
+	}
+	return s.client.ZAdd(ctx, pollDueKey, members...).Err()
+}
+
+// RemovePollDue удаляет страницы, которые больше не нужны ни одной подписке
+func (s *Storage) RemovePollDue(members []string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return s.client.ZRem(ctx, pollDueKey, args...).Err()
+}
@@ -3,11 +3,15 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"court-bot/types"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -27,28 +31,220 @@ func New(addr, password string, db int) *Storage {
 }
 
 type Subscription struct {
+	ID        string // номер подписки внутри чата ("1", "2", ...)
+	Name      string // название, которое видит пользователь ("Будни вечером у работы")
 	ChatID    int64
+	Sport     string // код вида спорта ("tenis", "padel", ...), пустой = теннис
+	City      string // код города ("warszawa", "krakow", ...), пустой = Варшава
 	Districts []string
 	Courts    []string // Court IDs from kluby.org
-	Days      []string // ["Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"]
-	TimeFrom  string   // "18:00"
-	TimeTo    string   // "21:00"
+	// Environments - типы кортов (types.EnvIndoor, EnvOutdoor, EnvBubble), пустой = крытые и балоны
+	Environments []string
+	MaxPrice     int      // максимальная цена за час в PLN, 0 = без ограничения
+	MinDuration  int      // минимальная непрерывная длительность в минутах, 0 = любая
+	Days         []string // ["Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"]
+	Dates        []string // конкретные даты ["2026-11-21"], если выбраны - используются вместо Days
+	TimeFrom     string   // "18:00"
+	TimeTo       string   // "21:00"
+	// DayWindows - свои окна времени для отдельных дней ("Sat" -> [{09:00 22:00}]), дни без записи используют TimeFrom/TimeTo
+	// При настройке времени по дням общего окна нет (TimeFrom/TimeTo пустые) - окна должны быть у каждого выбранного дня
+	DayWindows map[string][]TimeWindow
+	NotifyGone bool // зачеркивать в уведомлениях слоты, которые пропали
+	CreatedAt  time.Time
+
+	Paused         bool      // уведомления приостановлены (/pause)
+	PausedUntil    time.Time // когда возобновить автоматически (нулевое - до /resume)
+	ExpiresAt      time.Time // когда подписка заканчивается сама (нулевое - бессрочно)
+	ExpiryReminded bool      // напоминание о скором окончании уже отправлено
+	ExpiryNotified bool      // сообщение об окончании уже отправлено
+}
+
+// TimeWindow - окно времени начала слота
+type TimeWindow struct {
+	From string // "09:00"
+	To   string // "12:00"
 }
 
-// Save подписку в Redis
+// WindowsFor возвращает окна времени для дня недели ("Mon"); nil - в этот день слоты не ищем
+func (sub *Subscription) WindowsFor(day string) []TimeWindow {
+	if windows, ok := sub.DayWindows[day]; ok {
+		return windows
+	}
+	if sub.TimeFrom == "" || sub.TimeTo == "" {
+		return nil
+	}
+	return []TimeWindow{{From: sub.TimeFrom, To: sub.TimeTo}}
+}
+
+// HasTimeWindows проверяет, что время выбрано для каждого дня подписки: общее окно или свои окна каждого дня
+// Конкретные даты используют только общее окно
+func (sub *Subscription) HasTimeWindows() bool {
+	if sub.TimeFrom != "" && sub.TimeTo != "" {
+		return true
+	}
+	if len(sub.Dates) > 0 || len(sub.Days) == 0 {
+		return false
+	}
+	for _, day := range sub.Days {
+		if len(sub.DayWindows[day]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DisplayName возвращает название подписки (у перенесенных из старого формата его могло не быть)
+func (sub *Subscription) DisplayName() string {
+	if sub.Name != "" {
+		return sub.Name
+	}
+	return "Подписка " + sub.ID
+}
+
+// IsPaused проверяет, приостановлена ли подписка в момент now
+func (sub *Subscription) IsPaused(now time.Time) bool {
+	return sub.Paused && (sub.PausedUntil.IsZero() || now.Before(sub.PausedUntil))
+}
+
+// IsExpired проверяет, закончился ли срок подписки (или прошли все ее конкретные даты)
+func (sub *Subscription) IsExpired(now time.Time) bool {
+	return (!sub.ExpiresAt.IsZero() && !now.Before(sub.ExpiresAt)) || sub.DatesPassed(now)
+}
+
+// DatesPassed проверяет, что все конкретные даты подписки уже прошли (по времени ее города)
+func (sub *Subscription) DatesPassed(now time.Time) bool {
+	if len(sub.Dates) == 0 {
+		return false
+	}
+	today := now.In(types.CityLocation(sub.City)).Format("2006-01-02")
+	for _, date := range sub.Dates {
+		if date >= today {
+			return false
+		}
+	}
+	return true
+}
+
+// SetExpiry задает срок подписки (нулевое время - бессрочно) и сбрасывает отправленные напоминания
+func (sub *Subscription) SetExpiry(at time.Time) {
+	sub.ExpiresAt = at
+	sub.ExpiryReminded = false
+	sub.ExpiryNotified = false
+}
+
+// ===== Подписки =====
+//
+// sub:<chatID>:<id>   - JSON Subscription
+// subs:<chatID>       - ZSET ID подписок чата по времени создания
+// subs:seq:<chatID>   - счетчик для ID новых подписок чата
+// editing:<chatID>    - ID подписки, которую чат сейчас настраивает в мастере
+
+// editingTTL - незавершенная настройка подписки забывается через сутки
+const editingTTL = 24 * time.Hour
+
+func subKey(chatID int64, id string) string {
+	return fmt.Sprintf("sub:%d:%s", chatID, id)
+}
+
+// Save подписку в Redis (новой подписке без ID назначается следующий номер чата)
 func (s *Storage) Save(sub *Subscription) error {
-	key := fmt.Sprintf("sub:%d", sub.ChatID)
+	if sub.ID == "" {
+		n, err := s.client.Incr(ctx, fmt.Sprintf("subs:seq:%d", sub.ChatID)).Result()
+		if err != nil {
+			return err
+		}
+		sub.ID = strconv.FormatInt(n, 10)
+	}
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
 	data, err := json.Marshal(sub)
 	if err != nil {
 		return err
 	}
-	return s.client.Set(ctx, key, data, 0).Err()
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, subKey(sub.ChatID, sub.ID), data, 0)
+	pipe.ZAdd(ctx, fmt.Sprintf("subs:%d", sub.ChatID), redis.Z{Score: float64(sub.CreatedAt.Unix()), Member: sub.ID})
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
-// Get подписку по chat_id
-func (s *Storage) Get(chatID int64) (*Subscription, error) {
-	key := fmt.Sprintf("sub:%d", chatID)
-	val, err := s.client.Get(ctx, key).Result()
+// subscriptionUpdateAttempts - сколько раз повторять изменение подписки, если ее одновременно сохранили
+const subscriptionUpdateAttempts = 5
+
+// UpdateSubscription перечитывает подписку под WATCH, применяет fn и сохраняет результат,
+// если fn вернула true: изменения, сохраненные между чтением и записью (например, пользователь продлил срок),
+// не затираются - fn повторяется на актуальной копии. Возвращает сохраненную подписку (nil, если ее нет или fn отказалась)
+func (s *Storage) UpdateSubscription(chatID int64, id string, fn func(sub *Subscription) bool) (*Subscription, error) {
+	key := subKey(chatID, id)
+
+	var updated *Subscription
+	update := func(tx *redis.Tx) error {
+		updated = nil
+		val, err := tx.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var sub Subscription
+		if err := json.Unmarshal([]byte(val), &sub); err != nil {
+			return err
+		}
+		if !fn(&sub) {
+			return nil
+		}
+
+		data, err := json.Marshal(&sub)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			return nil
+		})
+		if err == nil {
+			updated = &sub
+		}
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < subscriptionUpdateAttempts; attempt++ {
+		err = s.client.Watch(ctx, update, key)
+		if err != redis.TxFailedErr {
+			return updated, err
+		}
+	}
+	return nil, err
+}
+
+// ErrSubscriptionNotFound - подписку удалили, пока ее настраивали
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// SaveSettings сохраняет настройки подписки из мастера под WATCH: пауза, срок и отметки о напоминаниях
+// берутся из актуальной записи, чтобы не затереть изменения, сделанные после чтения подписки (см. UpdateSubscription)
+func (s *Storage) SaveSettings(sub *Subscription) error {
+	updated, err := s.UpdateSubscription(sub.ChatID, sub.ID, func(fresh *Subscription) bool {
+		sub.Paused, sub.PausedUntil = fresh.Paused, fresh.PausedUntil
+		sub.ExpiresAt, sub.ExpiryReminded, sub.ExpiryNotified = fresh.ExpiresAt, fresh.ExpiryReminded, fresh.ExpiryNotified
+		*fresh = *sub
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		return ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+// Get подписку чата по ID
+func (s *Storage) Get(chatID int64, id string) (*Subscription, error) {
+	val, err := s.client.Get(ctx, subKey(chatID, id)).Result()
 	if err == redis.Nil {
 		return nil, nil
 	}
@@ -62,9 +258,27 @@ func (s *Storage) Get(chatID int64) (*Subscription, error) {
 	return &sub, nil
 }
 
+// ListChat все подписки чата в порядке создания
+func (s *Storage) ListChat(chatID int64) ([]*Subscription, error) {
+	ids, err := s.client.ZRange(ctx, fmt.Sprintf("subs:%d", chatID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]*Subscription, 0, len(ids))
+	for _, id := range ids {
+		sub, err := s.Get(chatID, id)
+		if err != nil || sub == nil {
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
 // List все подписки
 func (s *Storage) List() ([]*Subscription, error) {
-	keys, err := s.client.Keys(ctx, "sub:*").Result()
+	keys, err := s.client.Keys(ctx, "sub:*:*").Result()
 	if err != nil {
 		return nil, err
 	}
@@ -83,10 +297,149 @@ func (s *Storage) List() ([]*Subscription, error) {
 	return subs, nil
 }
 
-// Delete удаляет подписку
-func (s *Storage) Delete(chatID int64) error {
-	key := fmt.Sprintf("sub:%d", chatID)
-	return s.client.Del(ctx, key).Err()
+// Delete удаляет подписку вместе с ее состоянием слотов и отправленными уведомлениями
+func (s *Storage) Delete(chatID int64, id string) error {
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, subKey(chatID, id), slotsKey(chatID, id), sentKey(chatID, id))
+	pipe.ZRem(ctx, fmt.Sprintf("subs:%d", chatID), id)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Если подписку удалили посреди настройки - забываем и настройку
+	if editing, _ := s.client.Get(ctx, fmt.Sprintf("editing:%d", chatID)).Result(); editing == id {
+		return s.ClearEditing(chatID)
+	}
+	return nil
+}
+
+// SetEditing запоминает подписку, которую чат настраивает в мастере
+func (s *Storage) SetEditing(chatID int64, id string) error {
+	return s.client.Set(ctx, fmt.Sprintf("editing:%d", chatID), id, editingTTL).Err()
+}
+
+// ClearEditing завершает настройку подписки
+func (s *Storage) ClearEditing(chatID int64) error {
+	return s.client.Del(ctx, fmt.Sprintf("editing:%d", chatID)).Err()
+}
+
+// getEditing возвращает подписку, которую чат настраивает в мастере (nil если настройки нет)
+func (s *Storage) getEditing(chatID int64) (*Subscription, error) {
+	id, err := s.client.Get(ctx, fmt.Sprintf("editing:%d", chatID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(chatID, id)
+}
+
+// MigrateLegacySubscriptions переносит подписки из старого формата sub:<chatID> (одна на чат)
+// в sub:<chatID>:<id> вместе с состоянием слотов и отправленными уведомлениями
+func (s *Storage) MigrateLegacySubscriptions() (int, error) {
+	keys, err := s.client.Keys(ctx, "sub:*").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, key := range keys {
+		chatID, err := strconv.ParseInt(strings.TrimPrefix(key, "sub:"), 10, 64)
+		if err != nil {
+			continue // уже новый формат
+		}
+		ok, err := s.migrateLegacySubscription(key, chatID)
+		if err != nil {
+			return migrated, err
+		}
+		if ok {
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+// migrationAttempts - сколько раз повторять перенос подписки, если ее ключи изменились во время переноса
+const migrationAttempts = 5
+
+// migrateLegacySubscription переносит одну старую подписку одной транзакцией:
+// новая подписка, переименование slots/sent и удаление старого ключа либо происходят вместе, либо не происходят вовсе,
+// поэтому прерванную миграцию можно просто запустить снова - дубликатов не будет
+// Возвращает false, если старого ключа уже нет (подписку перенес другой экземпляр бота)
+func (s *Storage) migrateLegacySubscription(key string, chatID int64) (bool, error) {
+	oldSlots := fmt.Sprintf("slots:%d", chatID)
+	oldSent := fmt.Sprintf("sent:%d", chatID)
+
+	migrated := false
+	migrate := func(tx *redis.Tx) error {
+		val, err := tx.Get(ctx, key).Result()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var sub Subscription
+		if err := json.Unmarshal([]byte(val), &sub); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		// RENAME несуществующего ключа - ошибка, поэтому переименовываем только то, что есть
+		exists := make(map[string]bool, 2)
+		for _, old := range []string{oldSlots, oldSent} {
+			n, err := tx.Exists(ctx, old).Result()
+			if err != nil {
+				return err
+			}
+			exists[old] = n > 0
+		}
+
+		// Номер подписки выдается заранее: если транзакция не пройдет, пропадет только номер
+		n, err := tx.Incr(ctx, fmt.Sprintf("subs:seq:%d", chatID)).Result()
+		if err != nil {
+			return err
+		}
+		sub.ChatID = chatID
+		sub.ID = strconv.FormatInt(n, 10)
+		if sub.Name == "" {
+			sub.Name = "Подписка"
+		}
+		if sub.CreatedAt.IsZero() {
+			sub.CreatedAt = time.Now()
+		}
+		data, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, subKey(chatID, sub.ID), data, 0)
+			pipe.ZAdd(ctx, fmt.Sprintf("subs:%d", chatID), redis.Z{Score: float64(sub.CreatedAt.Unix()), Member: sub.ID})
+			if exists[oldSlots] {
+				pipe.Rename(ctx, oldSlots, slotsKey(chatID, sub.ID))
+			}
+			if exists[oldSent] {
+				pipe.Rename(ctx, oldSent, sentKey(chatID, sub.ID))
+			}
+			pipe.Del(ctx, key)
+			return nil
+		})
+		if err == nil {
+			migrated = true
+		}
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < migrationAttempts; attempt++ {
+		err = s.client.Watch(ctx, migrate, key, oldSlots, oldSent)
+		if err != redis.TxFailedErr {
+			return migrated, err
+		}
+	}
+	return false, err
 }
 
 func (s *Storage) GetCurrent(chatID int64) (*Subscription, error) {
@@ -99,8 +452,8 @@ func (s *Storage) GetCurrent(chatID int64) (*Subscription, error) {
 		return sub, nil
 	}
 
-	// Если нет check-подписки, проверяем обычную
-	return s.Get(chatID)
+	// Если нет check-подписки, берем подписку, которую чат настраивает
+	return s.getEditing(chatID)
 }
 
 func (s *Storage) GetCheck(chatID int64) (*Subscription, error) {
@@ -142,9 +495,9 @@ func (s *Storage) Ping() error {
 
 // ===== Кеширование районов =====
 
-// SaveDistricts сохраняет список районов Варшавы в кеш (TTL: 72 часа)
-func (s *Storage) SaveDistricts(districts []string) error {
-	key := "cache:districts:warsaw"
+// SaveDistricts сохраняет список районов города для вида спорта в кеш (TTL: 72 часа)
+func (s *Storage) SaveDistricts(city, sport string, districts []string) error {
+	key := fmt.Sprintf("cache:districts:%s:%s", city, sport)
 	data, err := json.Marshal(districts)
 	if err != nil {
 		return err
@@ -152,9 +505,9 @@ func (s *Storage) SaveDistricts(districts []string) error {
 	return s.client.Set(ctx, key, data, 72*time.Hour).Err()
 }
 
-// GetDistricts получает список районов из кеша
-func (s *Storage) GetDistricts() ([]string, error) {
-	key := "cache:districts:warsaw"
+// GetDistricts получает список районов города для вида спорта из кеша
+func (s *Storage) GetDistricts(city, sport string) ([]string, error) {
+	key := fmt.Sprintf("cache:districts:%s:%s", city, sport)
 	val, err := s.client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return nil, nil // кеш пуст
@@ -172,13 +525,13 @@ func (s *Storage) GetDistricts() ([]string, error) {
 // ===== Кеширование кортов =====
 
 // SaveCourts сохраняет список кортов для районов в кеш (TTL: 1 час)
-func (s *Storage) SaveCourts(districts []string, courts interface{}) error {
+func (s *Storage) SaveCourts(city, sport string, districts []string, courts interface{}) error {
 	// Создаем ключ из списка районов (отсортированный для консистентности)
 	sortedDistricts := make([]string, len(districts))
 	copy(sortedDistricts, districts)
 	sort.Strings(sortedDistricts)
 
-	key := fmt.Sprintf("cache:courts:%s", strings.Join(sortedDistricts, ","))
+	key := fmt.Sprintf("cache:courts:%s:%s:%s", city, sport, strings.Join(sortedDistricts, ","))
 	data, err := json.Marshal(courts)
 	if err != nil {
 		return err
@@ -187,12 +540,12 @@ func (s *Storage) SaveCourts(districts []string, courts interface{}) error {
 }
 
 // GetCourts получает список кортов для районов из кеша
-func (s *Storage) GetCourts(districts []string) ([]byte, error) {
+func (s *Storage) GetCourts(city, sport string, districts []string) ([]byte, error) {
 	sortedDistricts := make([]string, len(districts))
 	copy(sortedDistricts, districts)
 	sort.Strings(sortedDistricts)
 
-	key := fmt.Sprintf("cache:courts:%s", strings.Join(sortedDistricts, ","))
+	key := fmt.Sprintf("cache:courts:%s:%s:%s", city, sport, strings.Join(sortedDistricts, ","))
 	val, err := s.client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return nil, nil // кеш пуст
@@ -205,19 +558,28 @@ func (s *Storage) GetCourts(districts []string) ([]byte, error) {
 
 // ===== Хранение состояния слотов для нотификаций =====
 
-// SaveLastSlots сохраняет последние найденные слоты для подписки (TTL: 24 часа)
-func (s *Storage) SaveLastSlots(chatID int64, slots interface{}) error {
-	key := fmt.Sprintf("slots:%d", chatID)
+func slotsKey(chatID int64, subID string) string {
+	return fmt.Sprintf("slots:%d:%s", chatID, subID)
+}
+
+func sentKey(chatID int64, subID string) string {
+	return fmt.Sprintf("sent:%d:%s", chatID, subID)
+}
+
+// SaveLastSlots сохраняет последние найденные слоты для подписки (TTL: 14 дней - горизонт проверки,
+// состояние перезаписывается каждый цикл и не должно истекать в тихие периоды)
+func (s *Storage) SaveLastSlots(chatID int64, subID string, slots interface{}) error {
+	key := slotsKey(chatID, subID)
 	data, err := json.Marshal(slots)
 	if err != nil {
 		return err
 	}
-	return s.client.Set(ctx, key, data, 24*time.Hour).Err()
+	return s.client.Set(ctx, key, data, 14*24*time.Hour).Err()
 }
 
 // GetLastSlots получает последние слоты для подписки
-func (s *Storage) GetLastSlots(chatID int64) ([]byte, error) {
-	key := fmt.Sprintf("slots:%d", chatID)
+func (s *Storage) GetLastSlots(chatID int64, subID string) ([]byte, error) {
+	key := slotsKey(chatID, subID)
 	val, err := s.client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return nil, nil // нет сохраненных слотов
@@ -227,3 +589,124 @@ func (s *Storage) GetLastSlots(chatID int64) ([]byte, error) {
 	}
 	return []byte(val), nil
 }
+
+// SaveSentMessages сохраняет отправленные уведомления подписки для последующего редактирования (TTL: 14 дней)
+func (s *Storage) SaveSentMessages(chatID int64, subID string, msgs interface{}) error {
+	key := sentKey(chatID, subID)
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, key, data, 14*24*time.Hour).Err()
+}
+
+// GetSentMessages получает отправленные уведомления подписки
+func (s *Storage) GetSentMessages(chatID int64, subID string) ([]byte, error) {
+	val, err := s.client.Get(ctx, sentKey(chatID, subID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+// ===== Сессия kluby.org =====
+
+// SaveSession сохраняет cookies сессии kluby.org (TTL: 30 дней)
+func (s *Storage) SaveSession(data []byte) error {
+	return s.client.Set(ctx, "session:kluby", data, 30*24*time.Hour).Err()
+}
+
+// GetSession получает сохраненные cookies сессии kluby.org
+func (s *Storage) GetSession() ([]byte, error) {
+	val, err := s.client.Get(ctx, "session:kluby").Result()
+	if err == redis.Nil {
+		return nil, nil // сессия не сохранена
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+// ===== Каталог клубов =====
+
+// catalogUpsertAttempts - сколько раз повторять слияние записи каталога, если ее одновременно изменил другой загрузчик
+const catalogUpsertAttempts = 5
+
+// UpsertCatalogCourt дополняет запись каталога клуба новыми данными (без TTL)
+// Графики загружаются параллельно, поэтому чтение и запись идут под WATCH: если запись изменилась
+// между ними, слияние повторяется с актуальными данными
+func (s *Storage) UpsertCatalogCourt(court types.Court) error {
+	key := "catalog:court:" + court.ID
+	merge := func(tx *redis.Tx) error {
+		existing := &types.Court{ID: court.ID}
+		val, err := tx.Get(ctx, key).Result()
+		switch {
+		case err == redis.Nil:
+		case err != nil:
+			return err
+		default:
+			if err := json.Unmarshal([]byte(val), existing); err != nil {
+				return err
+			}
+		}
+		existing.Merge(court)
+
+		data, err := json.Marshal(existing)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, data, 0)
+			pipe.SAdd(ctx, "catalog:courts", court.ID)
+			return nil
+		})
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < catalogUpsertAttempts; attempt++ {
+		err = s.client.Watch(ctx, merge, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return err
+}
+
+// GetCatalogCourt получает запись каталога по ID клуба
+func (s *Storage) GetCatalogCourt(id string) (*types.Court, error) {
+	val, err := s.client.Get(ctx, "catalog:court:"+id).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var court types.Court
+	if err := json.Unmarshal([]byte(val), &court); err != nil {
+		return nil, err
+	}
+	return &court, nil
+}
+
+// ListCatalogCourts возвращает все клубы каталога
+func (s *Storage) ListCatalogCourts() ([]types.Court, error) {
+	ids, err := s.client.SMembers(ctx, "catalog:courts").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	courts := make([]types.Court, 0, len(ids))
+	for _, id := range ids {
+		court, err := s.GetCatalogCourt(id)
+		if err != nil || court == nil {
+			continue
+		}
+		courts = append(courts, *court)
+	}
+	return courts, nil
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ===== Слежение за отменами =====
+//
+// watches:<chatID> - JSON список Watch чата
+// watches:chats    - множество чатов, у которых есть слежения
+
+// Watch - слежение за конкретной забронированной ячейкой: уведомить, когда она освободится
+type Watch struct {
+	ID        string
+	ChatID    int64
+	Sport     string
+	City      string // город клуба (для часового пояса)
+	ClubID    string
+	ClubName  string
+	Court     string // название корта (CourtType), как в графике
+	Date      string // "2025-11-06"
+	Time      string // "19:00"
+	CreatedAt time.Time
+}
+
+// ErrWatchLimit - у чата уже максимальное число слежений
+var ErrWatchLimit = errors.New("watch limit reached")
+
+// watchUpdateAttempts - сколько раз повторять изменение слежений, если список одновременно изменили
+const watchUpdateAttempts = 5
+
+func watchesKey(chatID int64) string {
+	return fmt.Sprintf("watches:%d", chatID)
+}
+
+// AddWatch добавляет слежение, если у чата их меньше limit (иначе ErrWatchLimit)
+func (s *Storage) AddWatch(w Watch, limit int) error {
+	return s.updateWatches(w.ChatID, func(watches []Watch) ([]Watch, error) {
+		if len(watches) >= limit {
+			return nil, ErrWatchLimit
+		}
+		return append(watches, w), nil
+	})
+}
+
+// RemoveWatches удаляет слежения по ID и возвращает, сколько осталось
+// Остальные слежения не трогает, даже если их добавили или удалили одновременно с проверкой
+func (s *Storage) RemoveWatches(chatID int64, ids []string) (int, error) {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	remaining := 0
+	err := s.updateWatches(chatID, func(watches []Watch) ([]Watch, error) {
+		kept := make([]Watch, 0, len(watches))
+		for _, w := range watches {
+			if !remove[w.ID] {
+				kept = append(kept, w)
+			}
+		}
+		remaining = len(kept)
+		return kept, nil
+	})
+	return remaining, err
+}
+
+// updateWatches читает и перезаписывает список слежений чата под WATCH:
+// если список изменился между чтением и записью, изменение повторяется с актуальными данными
+func (s *Storage) updateWatches(chatID int64, fn func([]Watch) ([]Watch, error)) error {
+	key := watchesKey(chatID)
+	update := func(tx *redis.Tx) error {
+		watches, err := readWatches(tx, key)
+		if err != nil {
+			return err
+		}
+		watches, err = fn(watches)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(watches)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			// Пустой список удаляет ключ
+			if len(watches) == 0 {
+				pipe.Del(ctx, key)
+				pipe.SRem(ctx, "watches:chats", chatID)
+				return nil
+			}
+			pipe.Set(ctx, key, data, 0)
+			pipe.SAdd(ctx, "watches:chats", chatID)
+			return nil
+		})
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < watchUpdateAttempts; attempt++ {
+		err = s.client.Watch(ctx, update, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return err
+}
+
+// GetWatches получает слежения чата
+func (s *Storage) GetWatches(chatID int64) ([]Watch, error) {
+	return readWatches(s.client, watchesKey(chatID))
+}
+
+func readWatches(c redis.Cmdable, key string) ([]Watch, error) {
+	val, err := c.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var watches []Watch
+	if err := json.Unmarshal([]byte(val), &watches); err != nil {
+		return nil, err
+	}
+	return watches, nil
+}
+
+// ListWatchChats возвращает чаты, у которых есть слежения
+func (s *Storage) ListWatchChats() ([]int64, error) {
+	members, err := s.client.SMembers(ctx, "watches:chats").Result()
+	if err != nil {
+		return nil, err
+	}
+	chats := make([]int64, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+			chats = append(chats, id)
+		}
+	}
+	return chats, nil
+}
@@ -0,0 +1,95 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money represents a decimal amount with currency, stored in minor units to avoid float rounding
+type Money struct {
+	Minor    int64  // Amount in minor units (e.g., grosze: 6000 = 60,00)
+	Currency string // ISO currency code (e.g., "PLN")
+}
+
+// IsZero reports whether the price is unknown
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Major returns the amount in major units (e.g., 60.5 for 60,50 PLN)
+func (m Money) Major() float64 {
+	return float64(m.Minor) / 100
+}
+
+// String formats the amount the way kluby.org does (e.g., "60,00 PLN")
+func (m Money) String() string {
+	if m.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d,%02d %s", m.Minor/100, m.Minor%100, m.Currency)
+}
+
+// priceRe matches prices like "60,00 zł", "120 PLN", "75.50zł"
+var priceRe = regexp.MustCompile(`(?i)(\d{1,5})(?:[.,](\d{1,2}))?\s*(zł|zl|pln)`)
+
+// hourlyRe matches per-hour price markers: "80 zł/h", "80 zł / godz.", "80 zł za godzinę"
+var hourlyRe = regexp.MustCompile(`(?i)(zł|zl|pln)\s*(/\s*(h\b|godz)|za\s+(godz|h\b))`)
+
+// IsHourly reports whether the price in text is explicitly per hour
+// Grid cells usually show the price of the whole cell ("120 zł" for a 2-hour padel cell)
+func IsHourly(text string) bool {
+	return hourlyRe.MatchString(text)
+}
+
+// PerHour converts the price of a slot lasting minutes into a price per hour (rounded to grosze)
+func (m Money) PerHour(minutes int) Money {
+	if m.IsZero() || minutes <= 0 || minutes == 60 {
+		return m
+	}
+	return Money{Minor: (m.Minor*60 + int64(minutes)/2) / int64(minutes), Currency: m.Currency}
+}
+
+// ParseMoney extracts the first price from free text (cell text, tooltip, etc.)
+func ParseMoney(text string) (Money, bool) {
+	m := priceRe.FindStringSubmatch(text)
+	if m == nil {
+		return Money{}, false
+	}
+
+	major, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return Money{}, false
+	}
+
+	var minor int64
+	if m[2] != "" {
+		frac := m[2]
+		if len(frac) == 1 {
+			frac += "0"
+		}
+		minor, _ = strconv.ParseInt(frac, 10, 64)
+	}
+
+	return Money{Minor: major*100 + minor, Currency: "PLN"}, true
+}
+
+// UnmarshalJSON accepts both the struct form and the legacy string form ("0,00") used by saved slot state
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		parsed, _ := ParseMoney(strings.TrimSpace(legacy) + " PLN")
+		*m = parsed
+		return nil
+	}
+
+	type money Money
+	var v money
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
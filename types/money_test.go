@@ -0,0 +1,61 @@
+package types
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text string
+		want Money
+		ok   bool
+	}{
+		{"60,00 zł", Money{6000, "PLN"}, true},
+		{"Cena: 75.5zł/h", Money{7550, "PLN"}, true},
+		{"120 PLN", Money{12000, "PLN"}, true},
+		{"Rezerwuj 80 zl", Money{8000, "PLN"}, true},
+		{"od 50 zł do 70 zł", Money{5000, "PLN"}, true},
+		{"Rezerwuj", Money{}, false},
+		{"60,00", Money{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseMoney(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseMoney(%q) = %v, %v; want %v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestIsHourly(t *testing.T) {
+	tests := map[string]bool{
+		"Cena: 60,00 zł/h":      true,
+		"80 zł / godz.":         true,
+		"80 PLN za godzinę":     true,
+		"120 zł":                false,
+		"Rezerwuj 80 zł":        false,
+		"50 zł - hala squash/h": false,
+	}
+	for text, want := range tests {
+		if got := IsHourly(text); got != want {
+			t.Errorf("IsHourly(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestPerHour(t *testing.T) {
+	tests := []struct {
+		price   Money
+		minutes int
+		want    Money
+	}{
+		{Money{12000, "PLN"}, 120, Money{6000, "PLN"}}, // 2-hour padel cell
+		{Money{4000, "PLN"}, 30, Money{8000, "PLN"}},
+		{Money{5000, "PLN"}, 60, Money{5000, "PLN"}},
+		{Money{5000, "PLN"}, 45, Money{6667, "PLN"}}, // rounded to grosze
+		{Money{}, 90, Money{}},                       // unknown price stays unknown
+		{Money{5000, "PLN"}, 0, Money{5000, "PLN"}},
+	}
+	for _, tt := range tests {
+		if got := tt.price.PerHour(tt.minutes); got != tt.want {
+			t.Errorf("%v.PerHour(%d) = %v, want %v", tt.price, tt.minutes, got, tt.want)
+		}
+	}
+}
@@ -2,48 +2,274 @@ package types
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
-// Court represents a tennis court from kluby.org
+// CityWarsaw is the default city for legacy subscriptions
+const CityWarsaw = "warszawa"
+
+// City describes a city with clubs on kluby.org
+type City struct {
+	Code     string // Slug used in kluby.org URLs (e.g., /tenis/kluby/krakow)
+	Name     string // Display name
+	Timezone string // IANA timezone used for slot times in this city
+}
+
+// Cities lists supported cities in display order
+var Cities = []City{
+	{CityWarsaw, "Варшава", "Europe/Warsaw"},
+	{"krakow", "Краков", "Europe/Warsaw"},
+	{"wroclaw", "Вроцлав", "Europe/Warsaw"},
+	{"poznan", "Познань", "Europe/Warsaw"},
+	{"gdansk", "Гданьск", "Europe/Warsaw"},
+}
+
+// CityOrDefault returns the city code, falling back to Warsaw for legacy subscriptions
+func CityOrDefault(code string) string {
+	if code == "" {
+		return CityWarsaw
+	}
+	return code
+}
+
+// CityByCode returns the city with the given code (Warsaw if unknown)
+func CityByCode(code string) City {
+	code = CityOrDefault(code)
+	for _, c := range Cities {
+		if c.Code == code {
+			return c
+		}
+	}
+	return Cities[0]
+}
+
+var (
+	locationsMu sync.Mutex
+	locations   = make(map[string]*time.Location)
+)
+
+// CityLocation returns the local timezone of a city (time.Local if it can't be loaded)
+func CityLocation(code string) *time.Location {
+	tz := CityByCode(code).Timezone
+
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	if loc, ok := locations[tz]; ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = time.Local
+	}
+	locations[tz] = loc
+	return loc
+}
+
+// Sport codes (slug used in kluby.org URLs, e.g. /padel/kluby/warszawa)
+const (
+	SportTennis     = "tenis"
+	SportPadel      = "padel"
+	SportSquash     = "squash"
+	SportBadminton  = "badminton"
+	SportPickleball = "pickleball"
+)
+
+// Sport describes a discipline that can be tracked
+type Sport struct {
+	Code string
+	Name string
+}
+
+// Sports lists supported disciplines in display order
+var Sports = []Sport{
+	{SportTennis, "🎾 Теннис"},
+	{SportPadel, "🏓 Падел"},
+	{SportSquash, "🟡 Сквош"},
+	{SportBadminton, "🏸 Бадминтон"},
+	{SportPickleball, "🥒 Пиклбол"},
+}
+
+// SportOrDefault returns the sport code, falling back to tennis for legacy subscriptions
+func SportOrDefault(code string) string {
+	if code == "" {
+		return SportTennis
+	}
+	return code
+}
+
+// SportName returns the display name of a sport code
+func SportName(code string) string {
+	code = SportOrDefault(code)
+	for _, s := range Sports {
+		if s.Code == code {
+			return s.Name
+		}
+	}
+	return code
+}
+
+// Court environments
+const (
+	EnvIndoor  = "indoor"  // hala
+	EnvOutdoor = "outdoor" // korty odkryte
+	EnvBubble  = "bubble"  // balon (covered bubble)
+)
+
+// Environment describes a court environment option
+type Environment struct {
+	Code string
+	Name string
+}
+
+// Environments lists court environments in display order
+var Environments = []Environment{
+	{EnvIndoor, "🏢 Крытые (хала)"},
+	{EnvBubble, "🎈 Балон"},
+	{EnvOutdoor, "🌤 Открытые"},
+}
+
+// DefaultEnvironments is used when a subscription has no environment filter (outdoor is opt-in)
+var DefaultEnvironments = []string{EnvIndoor, EnvBubble}
+
+// EnvironmentName returns the display name of an environment code
+func EnvironmentName(code string) string {
+	for _, e := range Environments {
+		if e.Code == code {
+			return e.Name
+		}
+	}
+	return code
+}
+
+// Court represents a club from kluby.org together with its catalog details
 type Court struct {
-	ID       string
-	Name     string
-	District string
+	ID         string
+	Name       string
+	City       string
+	District   string
+	Address    string   // Street address from the club list (without the distance suffix)
+	DistanceKm float64  // Distance shown by kluby.org next to the address ("(3,2 km)"), reference point unknown; 0 if unknown
+	URL        string   // Club page on kluby.org
+	Sports     []string // Sport codes the club was seen with
+	Surfaces   []string // Surface codes seen in schedule headers (SurfaceHard, SurfaceClay, ...)
+	// CourtCounts is the number of courts (schedule grid columns) per sport code
+	CourtCounts map[string]int
+	Indoor      bool      // Has indoor or bubble courts
+	Outdoor     bool      // Has outdoor courts
+	UpdatedAt   time.Time // Last catalog update
+}
+
+// Surface codes
+const (
+	SurfaceHard       = "hard"
+	SurfaceClay       = "clay"
+	SurfaceGrass      = "grass"
+	SurfaceArtificial = "artificial" // artificial grass
+	SurfaceCarpet     = "carpet"
+)
+
+// Merge fills the catalog entry with non-empty details from another observation of the same club
+func (c *Court) Merge(o Court) {
+	if o.Name != "" {
+		c.Name = o.Name
+	}
+	if o.City != "" {
+		c.City = o.City
+	}
+	if o.District != "" {
+		c.District = o.District
+	}
+	if o.Address != "" {
+		c.Address = o.Address
+	}
+	if o.DistanceKm > 0 {
+		c.DistanceKm = o.DistanceKm
+	}
+	if o.URL != "" {
+		c.URL = o.URL
+	}
+	for sport, n := range o.CourtCounts {
+		if n <= 0 {
+			continue
+		}
+		if c.CourtCounts == nil {
+			c.CourtCounts = make(map[string]int)
+		}
+		c.CourtCounts[sport] = n
+	}
+	c.Sports = mergeStrings(c.Sports, o.Sports)
+	c.Surfaces = mergeStrings(c.Surfaces, o.Surfaces)
+	c.Indoor = c.Indoor || o.Indoor
+	c.Outdoor = c.Outdoor || o.Outdoor
+	if o.UpdatedAt.After(c.UpdatedAt) {
+		c.UpdatedAt = o.UpdatedAt
+	}
 }
 
-// TimeSlot represents an available booking slot
-type TimeSlot struct {
-	CourtID   string
-	CourtName string
-	DateTime  time.Time
-	Duration  int // minutes
+// mergeStrings appends values from b that are missing in a
+func mergeStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	for _, v := range a {
+		seen[v] = true
+	}
+	for _, v := range b {
+		if !seen[v] {
+			a = append(a, v)
+			seen[v] = true
+		}
+	}
+	return a
 }
 
 // Slot represents a bookable time slot from search API
 type Slot struct {
-	ClubID    string  // Court ID from kluby.org (e.g., "park-tennis-academy")
-	ClubName  string  // Display name (e.g., "Park Tennis Academy")
-	CourtType string  // Court type (e.g., "Hala (hard)", "Odkryte")
-	TypeID    string  // typ_obiektu from API
-	Date      string  // YYYY-MM-DD
-	Time      string  // HH:MM
-	Duration  int     // Number of 30-minute intervals (4 = 2 hours)
-	Price     string  // Price string (e.g., "60,00")
-	URL       string  // Booking URL
+	Sport       string // Sport code (e.g., "tenis", "padel")
+	ClubID      string // Court ID from kluby.org (e.g., "park-tennis-academy")
+	ClubName    string // Display name (e.g., "Park Tennis Academy")
+	CourtType   string // Court type (e.g., "Hala (hard)", "Odkryte")
+	Environment string // Court environment: EnvIndoor, EnvOutdoor or EnvBubble
+	TypeID      string // typ_obiektu from API
+	Date        string // YYYY-MM-DD
+	Time        string // HH:MM
+	Duration    int    // Duration in minutes (a single grid cell or a merged block of cells)
+	Price       Money  // Price per hour, converted from the cell price by Duration (zero if kluby.org doesn't show it)
+	URL         string // Booking URL
+}
+
+// EndTime returns the slot end time in "HH:MM" format
+func (s *Slot) EndTime() string {
+	start, err := time.Parse("15:04", s.Time)
+	if err != nil {
+		return s.Time
+	}
+	return start.Add(time.Duration(s.Duration) * time.Minute).Format("15:04")
 }
 
 // UniqueID generates a unique identifier for this slot
+// Tennis slots keep the legacy format so previously saved slot state stays valid
 func (s *Slot) UniqueID() string {
-	return fmt.Sprintf("%s_%s_%s_%s_%s", s.ClubID, s.TypeID, s.CourtType, s.Date, s.Time)
+	id := fmt.Sprintf("%s_%s_%s_%s_%s", s.ClubID, s.TypeID, s.CourtType, s.Date, s.Time)
+	if sport := SportOrDefault(s.Sport); sport != SportTennis {
+		id = sport + "_" + id
+	}
+	return id
+}
+
+// SlotLifecycle tracks when a slot was available on kluby.org
+type SlotLifecycle struct {
+	Slot          Slot
+	FirstSeen     time.Time // First time the slot was seen free
+	LastSeen      time.Time // Last check that still saw the slot free
+	AppearedAt    time.Time // Start of the current (or last) availability period
+	DisappearedAt time.Time // When the slot stopped being free, zero while it is still available
+	Appearances   int       // Number of availability periods (>1 means it was freed again after booking)
+	// Preexisting means the slot was already free the first time its page was checked
+	// (a new subscription or a date entering the horizon), so FirstSeen is not when it was released
+	Preexisting bool
 }
 
-// Subscription represents user's notification preferences
-type Subscription struct {
-	ChatID    int64
-	Districts []string
-	Courts    []string // Court IDs from kluby.org
-	Days      []string // ["Mon", "Tue", "Wed", ...]
-	TimeFrom  string   // "18:00"
-	TimeTo    string   // "21:00"
+// Available reports whether the slot was free at the last check
+func (l *SlotLifecycle) Available() bool {
+	return l.DisappearedAt.IsZero()
 }
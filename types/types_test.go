@@ -0,0 +1,22 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCourtMergeKeepsCountsPerSport(t *testing.T) {
+	c := Court{ID: "klub", Sports: []string{SportTennis}, CourtCounts: map[string]int{SportTennis: 6}}
+	c.Merge(Court{ID: "klub", Sports: []string{SportPadel}, Surfaces: []string{SurfaceHard}, CourtCounts: map[string]int{SportPadel: 2}})
+	c.Merge(Court{ID: "klub", Name: "Klub", Sports: []string{SportTennis}})
+
+	if want := map[string]int{SportTennis: 6, SportPadel: 2}; !reflect.DeepEqual(c.CourtCounts, want) {
+		t.Errorf("CourtCounts = %v, want %v", c.CourtCounts, want)
+	}
+	if want := []string{SportTennis, SportPadel}; !reflect.DeepEqual(c.Sports, want) {
+		t.Errorf("Sports = %v, want %v", c.Sports, want)
+	}
+	if c.Name != "Klub" || !reflect.DeepEqual(c.Surfaces, []string{SurfaceHard}) {
+		t.Errorf("merged court = %+v", c)
+	}
+}